@@ -0,0 +1,383 @@
+// Package breaker implements the circuit breaker pattern, to stop calling a failing dependency
+// until it recovers.
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWindow is the duration of the sliding window of the failure rate.
+	DefaultWindow = time.Minute
+	// DefaultWindowBuckets is the number of buckets of the sliding window.
+	DefaultWindowBuckets = 10
+	// DefaultMinRequests is the number of requests in the window below which the failure rate is not evaluated.
+	DefaultMinRequests = 10
+	// DefaultConsecutiveFailures is the consecutive failures threshold used when no threshold is set.
+	DefaultConsecutiveFailures = 5
+	// DefaultCooldown is the time spent in the open state before probing the dependency.
+	DefaultCooldown = 30 * time.Second
+	// DefaultHalfOpenProbes is the number of probes allowed in the half-open state.
+	DefaultHalfOpenProbes = 1
+)
+
+var (
+	// ErrOpenState is returned when the circuit breaker is open.
+	ErrOpenState = errors.New("breaker: circuit breaker is open")
+
+	// ErrTooManyProbes is returned when the circuit breaker is half-open and the probes limit is reached.
+	ErrTooManyProbes = errors.New("breaker: too many probes in half-open state")
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed lets the calls through, and counts their failures.
+	StateClosed State = iota
+	// StateHalfOpen lets a limited number of probes through, to decide whether the dependency recovered.
+	StateHalfOpen
+	// StateOpen rejects the calls until the cooldown is over.
+	StateOpen
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("unknown state: %d", int(s))
+	}
+}
+
+// Options configures a circuit breaker.
+type Options struct {
+	// Name identifies the circuit breaker in the state change callback.
+	Name string
+
+	// Window is the duration of the sliding window of the failure rate, 0 means DefaultWindow.
+	Window time.Duration
+
+	// WindowBuckets is the number of buckets of the sliding window, 0 means DefaultWindowBuckets.
+	WindowBuckets int
+
+	// MinRequests is the number of requests in the window below which the failure rate
+	// is not evaluated, 0 means DefaultMinRequests.
+	MinRequests uint
+
+	// FailureRate is the failure rate in the window, between 0 and 1, which opens the breaker.
+	// 0 disables the failure rate threshold.
+	FailureRate float64
+
+	// ConsecutiveFailures is the number of consecutive failures which opens the breaker.
+	// 0 disables the consecutive failures threshold. When both thresholds are disabled,
+	// DefaultConsecutiveFailures is used.
+	ConsecutiveFailures uint
+
+	// Cooldown is the time spent in the open state before probing the dependency, 0 means DefaultCooldown.
+	Cooldown time.Duration
+
+	// HalfOpenProbes is the number of calls let through in the half-open state, 0 means DefaultHalfOpenProbes.
+	// The breaker closes when they all succeed, and opens again at the first failure.
+	HalfOpenProbes uint
+
+	// IsFailure decides whether an error counts as a failure, nil means any non-nil error.
+	IsFailure func(err error) bool
+
+	// OnStateChange is called on every state change, if not nil.
+	// It is called with the lock of the breaker held, so it must not call the breaker.
+	OnStateChange func(name string, from State, to State)
+
+	// Now returns the current time, nil means time.Now.
+	Now func() time.Time
+}
+
+// Counts are the numbers of requests of the current window.
+type Counts struct {
+	Requests             uint
+	Successes            uint
+	Failures             uint
+	ConsecutiveSuccesses uint
+	ConsecutiveFailures  uint
+}
+
+// bucket holds the counts of a slice of the sliding window.
+type bucket struct {
+	start     time.Time
+	successes uint
+	failures  uint
+}
+
+// Breaker is a circuit breaker, it is safe for concurrent use.
+type Breaker struct {
+	opts Options
+
+	mu     sync.Mutex
+	state  State
+	expiry time.Time
+
+	// generation changes on every state change, the results of the calls started in a previous
+	// generation are ignored.
+	generation uint64
+
+	buckets    []bucket
+	bucketSize time.Duration
+
+	consecutiveSuccesses uint
+	consecutiveFailures  uint
+
+	probes uint
+}
+
+// New creates a circuit breaker, in the closed state.
+func New(opts Options) *Breaker {
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.WindowBuckets <= 0 {
+		opts.WindowBuckets = DefaultWindowBuckets
+	}
+	if opts.MinRequests == 0 {
+		opts.MinRequests = DefaultMinRequests
+	}
+	if opts.FailureRate <= 0 && opts.ConsecutiveFailures == 0 {
+		opts.ConsecutiveFailures = DefaultConsecutiveFailures
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultCooldown
+	}
+	if opts.HalfOpenProbes == 0 {
+		opts.HalfOpenProbes = DefaultHalfOpenProbes
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(err error) bool { return err != nil }
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	bucketSize := opts.Window / time.Duration(opts.WindowBuckets)
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+
+	return &Breaker{
+		opts:       opts,
+		buckets:    make([]bucket, opts.WindowBuckets),
+		bucketSize: bucketSize,
+	}
+}
+
+// Name returns the name of the circuit breaker.
+func (b *Breaker) Name() string {
+	return b.opts.Name
+}
+
+// State returns the current state of the circuit breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState(b.opts.Now())
+}
+
+// Counts returns the counts of the current window.
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.opts.Now()
+	b.currentState(now)
+	return b.counts(now)
+}
+
+// Allow checks whether a call can be made. On success, done must be called with the result of the call.
+// It returns ErrOpenState or ErrTooManyProbes when the call is rejected.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.opts.Now()
+	switch b.currentState(now) {
+	case StateOpen:
+		return nil, ErrOpenState
+	case StateHalfOpen:
+		if b.probes >= b.opts.HalfOpenProbes {
+			return nil, ErrTooManyProbes
+		}
+		b.probes++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			b.done(generation, err)
+		})
+	}, nil
+}
+
+// Execute calls fn if the circuit breaker allows it, and records its result.
+// A panic of fn counts as a failure, and is raised again.
+func (b *Breaker) Execute(fn func() error) error {
+	_, err := Execute(b, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// Execute calls fn if the circuit breaker allows it, records its result, and returns it.
+// A panic of fn counts as a failure, and is raised again.
+func Execute[T any](b *Breaker, fn func() (T, error)) (T, error) {
+	done, err := b.Allow()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	completed := false
+	defer func() {
+		if !completed {
+			r := recover()
+			done(fmt.Errorf("breaker: panic: %v", r))
+			panic(r)
+		}
+	}()
+
+	value, err := fn()
+	completed = true
+	done(err)
+	return value, err
+}
+
+// done records the result of a call.
+func (b *Breaker) done(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.opts.Now()
+	state := b.currentState(now)
+	if generation != b.generation {
+		return
+	}
+
+	if b.opts.IsFailure(err) {
+		b.onFailure(state, now)
+	} else {
+		b.onSuccess(state, now)
+	}
+}
+
+func (b *Breaker) onSuccess(state State, now time.Time) {
+	b.bucket(now).successes++
+	b.consecutiveSuccesses++
+	b.consecutiveFailures = 0
+
+	if state == StateHalfOpen && b.consecutiveSuccesses >= b.opts.HalfOpenProbes {
+		b.setState(StateClosed, now)
+	}
+}
+
+func (b *Breaker) onFailure(state State, now time.Time) {
+	b.bucket(now).failures++
+	b.consecutiveFailures++
+	b.consecutiveSuccesses = 0
+
+	switch state {
+	case StateHalfOpen:
+		b.setState(StateOpen, now)
+	case StateClosed:
+		if b.tripped(now) {
+			b.setState(StateOpen, now)
+		}
+	}
+}
+
+// tripped checks the thresholds.
+func (b *Breaker) tripped(now time.Time) bool {
+	if b.opts.ConsecutiveFailures > 0 && b.consecutiveFailures >= b.opts.ConsecutiveFailures {
+		return true
+	}
+	if b.opts.FailureRate > 0 {
+		counts := b.counts(now)
+		if counts.Requests >= b.opts.MinRequests &&
+			float64(counts.Failures)/float64(counts.Requests) >= b.opts.FailureRate {
+			return true
+		}
+	}
+	return false
+}
+
+// currentState moves from the open state to the half-open state once the cooldown is over.
+func (b *Breaker) currentState(now time.Time) State {
+	if b.state == StateOpen && !now.Before(b.expiry) {
+		b.setState(StateHalfOpen, now)
+	}
+	return b.state
+}
+
+func (b *Breaker) setState(state State, now time.Time) {
+	if b.state == state {
+		return
+	}
+
+	previous := b.state
+	b.state = state
+	b.generation++
+	b.probes = 0
+	b.consecutiveSuccesses = 0
+	b.consecutiveFailures = 0
+	for i := range b.buckets {
+		b.buckets[i] = bucket{}
+	}
+
+	if state == StateOpen {
+		b.expiry = now.Add(b.opts.Cooldown)
+	}
+
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(b.opts.Name, previous, state)
+	}
+}
+
+// bucket returns the bucket of the current time, resetting it when it is outdated.
+func (b *Breaker) bucket(now time.Time) *bucket {
+	start := now.Truncate(b.bucketSize)
+	index := int(start.UnixNano()/int64(b.bucketSize)) % len(b.buckets)
+	if index < 0 {
+		index += len(b.buckets)
+	}
+
+	bk := &b.buckets[index]
+	if !bk.start.Equal(start) {
+		*bk = bucket{start: start}
+	}
+	return bk
+}
+
+// counts sums the buckets of the window.
+func (b *Breaker) counts(now time.Time) Counts {
+	counts := Counts{
+		ConsecutiveSuccesses: b.consecutiveSuccesses,
+		ConsecutiveFailures:  b.consecutiveFailures,
+	}
+
+	oldest := now.Truncate(b.bucketSize).Add(-b.opts.Window + b.bucketSize)
+	for _, bk := range b.buckets {
+		if bk.start.IsZero() || bk.start.Before(oldest) {
+			continue
+		}
+		counts.Successes += bk.successes
+		counts.Failures += bk.failures
+	}
+	counts.Requests = counts.Successes + counts.Failures
+	return counts
+}
@@ -0,0 +1,190 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/internal"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var errFailed = errors.New("failed")
+
+func fail() error    { return errFailed }
+func succeed() error { return nil }
+
+func TestConsecutiveFailures(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestConsecutiveFailures")
+
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	changes := []string{}
+	b := New(Options{
+		Name:                "db",
+		ConsecutiveFailures: 3,
+		Cooldown:            10 * time.Second,
+		Now:                 clock.Now,
+		OnStateChange: func(name string, from, to State) {
+			changes = append(changes, name+": "+from.String()+" -> "+to.String())
+		},
+	})
+
+	assert.Equal(StateClosed, b.State())
+	assert.Equal(errFailed, b.Execute(fail))
+	assert.Equal(errFailed, b.Execute(fail))
+	assert.IsNil(b.Execute(succeed))
+	assert.Equal(errFailed, b.Execute(fail))
+	assert.Equal(errFailed, b.Execute(fail))
+	assert.Equal(StateClosed, b.State())
+	assert.Equal(uint(2), b.Counts().ConsecutiveFailures)
+
+	assert.Equal(errFailed, b.Execute(fail))
+	assert.Equal(StateOpen, b.State())
+
+	called := false
+	err := b.Execute(func() error {
+		called = true
+		return nil
+	})
+	assert.Equal(ErrOpenState, err)
+	assert.ShouldBeFalse(called)
+
+	// half-open after the cooldown, a failed probe opens again
+	clock.Add(10 * time.Second)
+	assert.Equal(StateHalfOpen, b.State())
+	assert.Equal(errFailed, b.Execute(fail))
+	assert.Equal(StateOpen, b.State())
+
+	// a successful probe closes
+	clock.Add(10 * time.Second)
+	assert.IsNil(b.Execute(succeed))
+	assert.Equal(StateClosed, b.State())
+
+	assert.Equal([]string{
+		"db: closed -> open",
+		"db: open -> half-open",
+		"db: half-open -> open",
+		"db: open -> half-open",
+		"db: half-open -> closed",
+	}, changes)
+}
+
+func TestFailureRate(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestFailureRate")
+
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	b := New(Options{
+		Window:        10 * time.Second,
+		WindowBuckets: 10,
+		MinRequests:   4,
+		FailureRate:   0.5,
+		Now:           clock.Now,
+	})
+
+	// below the minimum number of requests
+	b.Execute(fail)
+	b.Execute(fail)
+	b.Execute(succeed)
+	assert.Equal(StateClosed, b.State())
+
+	// the failures slide out of the window
+	clock.Add(10 * time.Second)
+	b.Execute(succeed)
+	b.Execute(succeed)
+	b.Execute(fail)
+	assert.Equal(StateClosed, b.State())
+	assert.Equal(Counts{Requests: 3, Successes: 2, Failures: 1, ConsecutiveFailures: 1}, b.Counts())
+
+	b.Execute(fail)
+	assert.Equal(StateOpen, b.State())
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestHalfOpenProbes")
+
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	b := New(Options{
+		ConsecutiveFailures: 1,
+		Cooldown:            time.Second,
+		HalfOpenProbes:      2,
+		Now:                 clock.Now,
+	})
+
+	b.Execute(fail)
+	clock.Add(time.Second)
+
+	done1, err := b.Allow()
+	assert.IsNil(err)
+	done2, err := b.Allow()
+	assert.IsNil(err)
+	_, err = b.Allow()
+	assert.Equal(ErrTooManyProbes, err)
+
+	done1(nil)
+	assert.Equal(StateHalfOpen, b.State())
+	done2(nil)
+	assert.Equal(StateClosed, b.State())
+
+	// results of a previous generation are ignored
+	done3, _ := b.Allow()
+	b.Execute(fail)
+	assert.Equal(StateOpen, b.State())
+	done3(nil)
+	assert.Equal(StateOpen, b.State())
+}
+
+func TestExecute(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestExecute")
+
+	errIgnored := errors.New("ignored")
+	b := New(Options{
+		ConsecutiveFailures: 1,
+		IsFailure: func(err error) bool {
+			return err != nil && !errors.Is(err, errIgnored)
+		},
+	})
+
+	value, err := Execute(b, func() (int, error) { return 42, nil })
+	assert.IsNil(err)
+	assert.Equal(42, value)
+
+	_, err = Execute(b, func() (int, error) { return 0, errIgnored })
+	assert.Equal(errIgnored, err)
+	assert.Equal(StateClosed, b.State())
+
+	func() {
+		defer func() {
+			assert.Equal("boom", recover())
+		}()
+		Execute(b, func() (int, error) { panic("boom") })
+	}()
+	assert.Equal(StateOpen, b.State())
+
+	_, err = Execute(b, func() (int, error) { return 42, nil })
+	assert.Equal(ErrOpenState, err)
+}
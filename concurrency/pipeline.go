@@ -0,0 +1,407 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The pipeline stages below are functions rather than methods of Channel, since they change the
+// type of the values. Every stage runs in its own goroutine and closes its output channel when the
+// input channel is closed or ctx is done.
+//
+// The stages which may fail return an error channel along with the output channel. The first error
+// stops the stage: it is sent on the error channel, which has a buffer of one, and both channels are
+// closed. Cancel ctx to stop the upstream stages as well, MergeErrors helps to watch several stages.
+
+// Map applies fn to each value of in.
+func Map[T, U any](ctx context.Context, in <-chan T, fn func(ctx context.Context, value T) (U, error)) (<-chan U, <-chan error) {
+	out := make(chan U)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		for v := range orDone(ctx, in) {
+			u, err := fn(ctx, v)
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- u:
+			}
+		}
+	}()
+
+	return out, errc
+}
+
+// Filter keeps the values of in for which keep returns true.
+func Filter[T any](ctx context.Context, in <-chan T, keep func(value T) bool) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		for v := range orDone(ctx, in) {
+			if !keep(v) {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+
+	return out
+}
+
+// Batch groups the values of in into slices of up to size values. A batch is sent once it is full,
+// or maxWait after its first value, whichever comes first. A maxWait <= 0 only sends full batches,
+// and the last one when in is closed.
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	if size < 1 {
+		size = 1
+	}
+	out := make(chan []T)
+
+	go func() {
+		defer close(out)
+
+		var (
+			batch []T
+			timer *time.Timer
+			timeC <-chan time.Time
+		)
+		stopTimer := func() {
+			if timer != nil {
+				timer.Stop()
+				timer, timeC = nil, nil
+			}
+		}
+		defer stopTimer()
+
+		flush := func() bool {
+			stopTimer()
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- batch:
+				batch = nil
+				return true
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					timeC = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			case <-timeC:
+				timer, timeC = nil, nil
+				if !flush() {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// FanOut applies fn to the values of in with the given number of workers. The values are sent as
+// soon as they are ready, so their order is not kept, see FanOutOrdered.
+func FanOut[T, U any](ctx context.Context, in <-chan T, workers int, fn func(ctx context.Context, value T) (U, error)) (<-chan U, <-chan error) {
+	if workers < 1 {
+		workers = 1
+	}
+	out := make(chan U)
+	errc := make(chan error, 1)
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for v := range orDone(ctx, in) {
+				u, err := fn(ctx, v)
+				if err != nil {
+					select {
+					case errc <- err:
+					default:
+					}
+					cancel()
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- u:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+		close(errc)
+	}()
+
+	return out, errc
+}
+
+// FanOutOrdered is like FanOut, but it sends the results in the order of in. A slow value holds
+// the results behind it, up to the number of workers.
+func FanOutOrdered[T, U any](ctx context.Context, in <-chan T, workers int, fn func(ctx context.Context, value T) (U, error)) (<-chan U, <-chan error) {
+	if workers < 1 {
+		workers = 1
+	}
+	out := make(chan U)
+	errc := make(chan error, 1)
+	ctx, cancel := context.WithCancel(ctx)
+
+	type job struct {
+		index int
+		value T
+	}
+	type result struct {
+		index int
+		value U
+		err   error
+	}
+	jobs := make(chan job)
+	results := make(chan result)
+	// slots bounds the values in flight or waiting to be sent in order, it is released as the
+	// results are sent.
+	slots := make(chan struct{}, workers)
+
+	go func() {
+		defer close(jobs)
+
+		index := 0
+		for v := range orDone(ctx, in) {
+			select {
+			case <-ctx.Done():
+				return
+			case slots <- struct{}{}:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- job{index: index, value: v}:
+			}
+			index++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				u, err := fn(ctx, j.value)
+				select {
+				case <-ctx.Done():
+					return
+				case results <- result{index: j.index, value: u, err: err}:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+		defer cancel()
+
+		// reorder holds the results ahead of the next one to send
+		reorder := make(map[int]result, workers)
+		next := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case r, ok := <-results:
+				if !ok {
+					return
+				}
+				reorder[r.index] = r
+			}
+
+			for {
+				res, ok := reorder[next]
+				if !ok {
+					break
+				}
+				delete(reorder, next)
+				if res.err != nil {
+					errc <- res.err
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- res.value:
+				}
+				next++
+				<-slots
+			}
+		}
+	}()
+
+	return out, errc
+}
+
+// Buffer decouples a slow consumer from its producer with a buffer of size values.
+func Buffer[T any](ctx context.Context, in <-chan T, size int) <-chan T {
+	if size < 0 {
+		size = 0
+	}
+	out := make(chan T, size)
+
+	go func() {
+		defer close(out)
+
+		for v := range orDone(ctx, in) {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+
+	return out
+}
+
+// Throttle sends the values of in with at least interval between them. No value is dropped,
+// the values wait their turn.
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		var last time.Time
+		for v := range orDone(ctx, in) {
+			if wait := interval - time.Since(last); !last.IsZero() && wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+				last = time.Now()
+			}
+		}
+	}()
+
+	return out
+}
+
+// Debounce sends the latest value of in once no new value arrived for wait. The values received
+// in between are dropped. The pending value is sent when in is closed.
+func Debounce[T any](ctx context.Context, in <-chan T, wait time.Duration) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		var (
+			latest  T
+			pending bool
+			timer   = time.NewTimer(wait)
+		)
+		defer timer.Stop()
+		if !timer.Stop() {
+			<-timer.C
+		}
+
+		send := func() bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- latest:
+				pending = false
+				return true
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					if pending {
+						send()
+					}
+					return
+				}
+				latest, pending = v, true
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(wait)
+			case <-timer.C:
+				if pending && !send() {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// MergeErrors merges the error channels of several stages into one, which is closed once all of
+// them are closed.
+func MergeErrors(ctx context.Context, errcs ...<-chan error) <-chan error {
+	return NewChannel[error]().FanIn(ctx, errcs...)
+}
+
+// orDone reads from in until it is closed or ctx is done.
+func orDone[T any](ctx context.Context, in <-chan T) <-chan T {
+	return NewChannel[T]().OrDone(ctx, in)
+}
@@ -0,0 +1,234 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/internal"
+)
+
+func collect[T any](c <-chan T) []T {
+	var values []T
+	for v := range c {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestMap")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewChannel[int]()
+	out, errc := Map(ctx, c.Generate(ctx, 1, 2, 3), func(_ context.Context, v int) (string, error) {
+		return strconv.Itoa(v * 2), nil
+	})
+	assert.Equal([]string{"2", "4", "6"}, collect(out))
+	assert.IsNil(<-errc)
+
+	out, errc = Map(ctx, c.Generate(ctx, 1, 2, 3), func(_ context.Context, v int) (string, error) {
+		if v == 2 {
+			return "", errors.New("error")
+		}
+		return strconv.Itoa(v), nil
+	})
+	assert.Equal([]string{"1"}, collect(out))
+	assert.Equal("error", (<-errc).Error())
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestFilter")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewChannel[int]()
+	out := Filter(ctx, c.Generate(ctx, 1, 2, 3, 4), func(v int) bool { return v%2 == 0 })
+	assert.Equal([]int{2, 4}, collect(out))
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestBatch")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewChannel[int]()
+	out := Batch(ctx, c.Generate(ctx, 1, 2, 3, 4, 5), 2, 0)
+	assert.Equal([][]int{{1, 2}, {3, 4}, {5}}, collect(out))
+
+	// the batch is sent after maxWait when it is not full
+	in := make(chan int)
+	out = Batch(ctx, in, 10, 20*time.Millisecond)
+	in <- 1
+	in <- 2
+	start := time.Now()
+	assert.Equal([]int{1, 2}, <-out)
+	assert.ShouldBeTrue(time.Since(start) < time.Second)
+	in <- 3
+	close(in)
+	assert.Equal([][]int{{3}}, collect(out))
+}
+
+func TestFanOut(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestFanOut")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	values := []int{5, 4, 3, 2, 1, 0}
+	slow := func(_ context.Context, v int) (int, error) {
+		time.Sleep(time.Duration(v) * 5 * time.Millisecond)
+		return v * 10, nil
+	}
+
+	c := NewChannel[int]()
+	out, errc := FanOut(ctx, c.Generate(ctx, values...), 3, slow)
+	results := collect(out)
+	sort.Ints(results)
+	assert.Equal([]int{0, 10, 20, 30, 40, 50}, results)
+	assert.IsNil(<-errc)
+
+	out, errc = FanOutOrdered(ctx, c.Generate(ctx, values...), 3, slow)
+	assert.Equal([]int{50, 40, 30, 20, 10, 0}, collect(out))
+	assert.IsNil(<-errc)
+}
+
+func TestFanOutOrderedWorkers(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestFanOutOrderedWorkers")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var running, peak int32
+	fn := func(_ context.Context, v int) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(v%3) * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return v, nil
+	}
+
+	values := make([]int, 50)
+	for i := range values {
+		values[i] = i
+	}
+	c := NewChannel[int]()
+	out, errc := FanOutOrdered(ctx, c.Generate(ctx, values...), 3, fn)
+	assert.Equal(values, collect(out))
+	assert.IsNil(<-errc)
+	assert.ShouldBeTrue(atomic.LoadInt32(&peak) <= 3)
+}
+
+func TestFanOutError(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestFanOutError")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fail := func(_ context.Context, v int) (int, error) {
+		if v == 3 {
+			return 0, errors.New("error")
+		}
+		return v, nil
+	}
+
+	c := NewChannel[int]()
+	out, errc := FanOut(ctx, c.Repeat(ctx, 1, 2, 3), 2, fail)
+	collect(out)
+	assert.Equal("error", (<-errc).Error())
+
+	out, errc = FanOutOrdered(ctx, c.Generate(ctx, 1, 2, 3, 4), 2, fail)
+	assert.Equal([]int{1, 2}, collect(out))
+	assert.Equal("error", (<-errc).Error())
+}
+
+func TestBuffer(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestBuffer")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan int)
+	out := Buffer(ctx, in, 3)
+	// the producer is not blocked by the consumer
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	close(in)
+	assert.Equal([]int{0, 1, 2}, collect(out))
+}
+
+func TestThrottle(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestThrottle")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewChannel[int]()
+	start := time.Now()
+	out := Throttle(ctx, c.Generate(ctx, 1, 2, 3), 20*time.Millisecond)
+	assert.Equal([]int{1, 2, 3}, collect(out))
+	assert.ShouldBeTrue(time.Since(start) >= 40*time.Millisecond)
+}
+
+func TestDebounce(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDebounce")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan int)
+	out := Debounce(ctx, in, 30*time.Millisecond)
+
+	in <- 1
+	in <- 2
+	in <- 3
+	assert.Equal(3, <-out)
+
+	in <- 4
+	close(in)
+	assert.Equal([]int{4}, collect(out))
+}
+
+func TestMergeErrors(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestMergeErrors")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewChannel[int]()
+	mapped, errc1 := Map(ctx, c.Generate(ctx, 1), func(_ context.Context, v int) (int, error) {
+		return v, nil
+	})
+	out, errc2 := Map(ctx, mapped, func(_ context.Context, v int) (int, error) {
+		return 0, errors.New("error")
+	})
+	collect(out)
+
+	errs := collect(MergeErrors(ctx, errc1, errc2))
+	assert.Equal(1, len(errs))
+	assert.Equal("error", errs[0].Error())
+}
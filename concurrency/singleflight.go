@@ -0,0 +1,199 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FlightResult is the result of a Group call, sent by DoChan.
+type FlightResult[V any] struct {
+	Val    V
+	Err    error
+	Shared bool
+}
+
+// call is an in-flight or cached call of a Group.
+type call[V any] struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+
+	val V
+	err error
+	// shared is set before done is closed, when other callers joined the call while it was in flight.
+	shared bool
+
+	// waiters is the number of callers waiting for the result, dups is the number of callers which
+	// joined the call after the first one, and expires is set once the call is done when the result
+	// is cached. They are guarded by the mutex of the group.
+	waiters int
+	dups    int
+	expires time.Time
+}
+
+// Group collapses the concurrent calls with the same key into one: the first caller runs the
+// function and the other ones wait for its result. It suits cache misses or token refreshes.
+//
+// A caller stops waiting when its context is done, without canceling the shared call. The shared
+// call is canceled only when all of its callers gave up. The zero Group is ready to use, and does
+// not cache the results.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+	ttl   time.Duration
+}
+
+// NewGroup creates a group which shares a successful result for ttl after the call is done,
+// so that the calls just after it don't run the function again. The result is dropped when it
+// expires. A ttl of 0 disables it.
+func NewGroup[K comparable, V any](ttl time.Duration) *Group[K, V] {
+	return &Group[K, V]{
+		calls: make(map[K]*call[V]),
+		ttl:   ttl,
+	}
+}
+
+// Do runs fn for the key, or waits for the call of the key in flight, and returns its result.
+// shared reports whether the result was given to several callers: like x/sync/singleflight, it is
+// true for the caller which ran fn too when other callers joined it, and for the callers getting
+// a cached result.
+//
+// fn runs in its own goroutine, with a context keeping the values of ctx but not its cancellation.
+// A panic of fn is returned as an error.
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (v V, err error, shared bool) {
+	c, shared := g.join(ctx, key, fn)
+
+	select {
+	case <-c.done:
+		g.mu.Lock()
+		c.waiters--
+		g.mu.Unlock()
+		return c.val, c.err, shared || c.shared
+	case <-ctx.Done():
+		g.leave(key, c)
+		return v, ctx.Err(), shared
+	}
+}
+
+// DoChan is like Do, but it returns a channel receiving the result.
+func (g *Group[K, V]) DoChan(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) <-chan FlightResult[V] {
+	ch := make(chan FlightResult[V], 1)
+
+	go func() {
+		v, err, shared := g.Do(ctx, key, fn)
+		ch <- FlightResult[V]{Val: v, Err: err, Shared: shared}
+	}()
+
+	return ch
+}
+
+// Forget forgets the call of the key, in flight or cached: the next call of the key runs fn
+// again. The callers waiting for the forgotten call still get its result.
+func (g *Group[K, V]) Forget(key K) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+}
+
+func (g *Group[K, V]) join(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (*call[V], bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+
+	if c, ok := g.calls[key]; ok {
+		if c.expires.IsZero() || time.Now().Before(c.expires) {
+			c.waiters++
+			c.dups++
+			return c, true
+		}
+		delete(g.calls, key)
+	}
+
+	callCtx, cancel := context.WithCancel(detachedContext{ctx})
+	c := &call[V]{
+		done:    make(chan struct{}),
+		cancel:  cancel,
+		waiters: 1,
+	}
+	g.calls[key] = c
+
+	go g.run(callCtx, key, c, fn)
+
+	return c, false
+}
+
+func (g *Group[K, V]) run(ctx context.Context, key K, c *call[V], fn func(ctx context.Context) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("singleflight: panic: %v", r)
+		}
+		c.cancel()
+
+		g.mu.Lock()
+		c.shared = c.dups > 0
+		if g.calls[key] == c {
+			if g.ttl > 0 && c.err == nil {
+				c.expires = time.Now().Add(g.ttl)
+				// drop the result once expired, unless the key was joined or forgotten meanwhile
+				time.AfterFunc(g.ttl, func() {
+					g.mu.Lock()
+					if g.calls[key] == c {
+						delete(g.calls, key)
+					}
+					g.mu.Unlock()
+				})
+			} else {
+				delete(g.calls, key)
+			}
+		}
+		close(c.done)
+		g.mu.Unlock()
+	}()
+
+	c.val, c.err = fn(ctx)
+}
+
+// leave removes a caller which gave up, the call is canceled when it was the last one.
+func (g *Group[K, V]) leave(key K, c *call[V]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+
+	select {
+	case <-c.done:
+	default:
+		c.cancel()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+	}
+}
+
+// detachedContext keeps the values of its parent, but not its deadline nor its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
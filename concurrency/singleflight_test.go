@@ -0,0 +1,172 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/internal"
+)
+
+func TestGroupDo(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestGroupDo")
+
+	var g Group[string, int]
+	var calls int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	var sharedCount int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, shared := g.Do(context.Background(), "key", fn)
+			assert.Equal(42, v)
+			assert.IsNil(err)
+			if shared {
+				atomic.AddInt32(&sharedCount, 1)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+	// the caller which ran fn shares its result too
+	assert.Equal(int32(10), atomic.LoadInt32(&sharedCount))
+
+	// no ttl: the next call runs fn again
+	_, _, shared := g.Do(context.Background(), "key", fn)
+	assert.ShouldBeFalse(shared)
+	assert.Equal(int32(2), atomic.LoadInt32(&calls))
+
+	_, err, _ := g.Do(context.Background(), "panic", func(context.Context) (int, error) {
+		panic("boom")
+	})
+	assert.Equal("singleflight: panic: boom", err.Error())
+}
+
+func TestGroupCancel(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestGroupCancel")
+
+	g := NewGroup[string, string](0)
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (string, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			close(canceled)
+			return "", ctx.Err()
+		case <-release:
+			return "value", nil
+		}
+	}
+
+	// the first waiter gives up, the shared call keeps running for the second one
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	res1 := g.DoChan(ctx1, "key", fn)
+	<-started
+	res2 := g.DoChan(context.Background(), "key", fn)
+	time.Sleep(10 * time.Millisecond)
+
+	cancel1()
+	r := <-res1
+	assert.Equal(context.Canceled, r.Err)
+
+	close(release)
+	r = <-res2
+	assert.Equal("value", r.Val)
+	assert.IsNil(r.Err)
+	assert.ShouldBeTrue(r.Shared)
+
+	// the shared call is canceled once every waiter gave up
+	started = make(chan struct{})
+	release = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	res := g.DoChan(ctx, "other", fn)
+	<-started
+	cancel()
+	assert.Equal(context.Canceled, (<-res).Err)
+	<-canceled
+}
+
+func TestGroupTTL(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestGroupTTL")
+
+	g := NewGroup[int, int](50 * time.Millisecond)
+	var calls int32
+	fn := func(context.Context) (int, error) {
+		return int(atomic.AddInt32(&calls, 1)), nil
+	}
+
+	v, _, shared := g.Do(context.Background(), 1, fn)
+	assert.Equal(1, v)
+	assert.ShouldBeFalse(shared)
+
+	v, _, shared = g.Do(context.Background(), 1, fn)
+	assert.Equal(1, v)
+	assert.ShouldBeTrue(shared)
+
+	// the cached call has no waiter left
+	g.mu.Lock()
+	assert.Equal(0, g.calls[1].waiters)
+	g.mu.Unlock()
+
+	g.Forget(1)
+	v, _, _ = g.Do(context.Background(), 1, fn)
+	assert.Equal(2, v)
+
+	time.Sleep(60 * time.Millisecond)
+	v, _, _ = g.Do(context.Background(), 1, fn)
+	assert.Equal(3, v)
+
+	// errors are not cached
+	_, err, _ := g.Do(context.Background(), 2, func(context.Context) (int, error) {
+		return 0, errors.New("error")
+	})
+	assert.IsNotNil(err)
+	v, err, _ = g.Do(context.Background(), 2, fn)
+	assert.Equal(4, v)
+	assert.IsNil(err)
+}
+
+func TestGroupTTLExpiry(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestGroupTTLExpiry")
+
+	g := NewGroup[int, int](20 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		g.Do(context.Background(), i, func(context.Context) (int, error) {
+			return 0, nil
+		})
+	}
+
+	size := func() int {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return len(g.calls)
+	}
+	assert.Equal(10, size())
+
+	// the expired results are dropped without joining their keys again
+	deadline := time.Now().Add(time.Second)
+	for size() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(0, size())
+}
@@ -1,6 +1,7 @@
 package di
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -102,3 +103,94 @@ func (d *DAG) explainServiceImplem(edge EdgeService) (dependencies, dependents [
 
 	return dependencies, dependents
 }
+
+// removeDependencies removes the dependencies of a service in the DAG, but keeps its dependents.
+// It returns the removed dependencies.
+func (d *DAG) removeDependencies(scopeID, scopeName, serviceName string) []EdgeService {
+	edge := newEdgeService(scopeID, scopeName, serviceName)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dependencies, _ := d.explainServiceImplem(edge)
+
+	for _, dependency := range dependencies {
+		delete(d.dependents[dependency], edge)
+	}
+
+	delete(d.dependencies, edge)
+
+	return dependencies
+}
+
+// orderedDependents returns the recursive dependents of a service in the DAG, sorted such that
+// every service comes after its own dependencies. Services involved in a cycle are returned last.
+func (d *DAG) orderedDependents(scopeID, scopeName, serviceName string) []EdgeService {
+	edge := newEdgeService(scopeID, scopeName, serviceName)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	// collect recursive dependents
+	set := map[EdgeService]struct{}{}
+	queue := []EdgeService{edge}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for dependent := range d.dependents[current] {
+			if _, ok := set[dependent]; !ok && dependent != edge {
+				set[dependent] = struct{}{}
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	// topological sort, limited to the collected dependents
+	pending := map[EdgeService]int{}
+	for dependent := range set {
+		for dependency := range d.dependencies[dependent] {
+			if _, ok := set[dependency]; ok {
+				pending[dependent]++
+			}
+		}
+	}
+
+	sortEdges := func(edges []EdgeService) {
+		// order by name to have a deterministic output in unit tests
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i].Service == edges[j].Service {
+				return edges[i].ScopeID < edges[j].ScopeID
+			}
+			return edges[i].Service < edges[j].Service
+		})
+	}
+
+	output := []EdgeService{}
+	ready := filter(keys(set), func(item EdgeService, _ int) bool {
+		return pending[item] == 0
+	})
+	for len(ready) > 0 {
+		sortEdges(ready)
+		current := ready[0]
+		ready = ready[1:]
+
+		output = append(output, current)
+		delete(set, current)
+
+		for dependent := range d.dependents[current] {
+			if _, ok := set[dependent]; ok {
+				pending[dependent]--
+				if pending[dependent] == 0 {
+					ready = append(ready, dependent)
+				}
+			}
+		}
+	}
+
+	// circular dependencies
+	remaining := keys(set)
+	sortEdges(remaining)
+
+	return append(output, remaining...)
+}
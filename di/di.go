@@ -139,7 +139,7 @@ func MustInvokeNamed[T any](i Injector, name string) T {
 }
 
 // InvokeStruct invokes services located in struct properties.
-// The struct fields must be tagged with `do:""` or `do:"name"`, where `name` is the service name in the DI container.
+// The struct fields must be tagged with `di:""` or `di:"name"`, where `name` is the service name in the DI container.
 // If the service is not found in the DI container, an error is returned.
 // If the service is found but not assignable to the struct field, an error is returned.
 func InvokeStruct[T any](i Injector) (*T, error) {
@@ -163,7 +163,7 @@ func InvokeStruct[T any](i Injector) (*T, error) {
 }
 
 // InvokeStruct invokes services located in struct properties.
-// The struct fields must be tagged with `do:""` or `do:"name"`, where `name` is the service name in the DI container.
+// The struct fields must be tagged with `di:""` or `di:"name"`, where `name` is the service name in the DI container.
 // If the service is not found in the DI container, an error is returned.
 // If the service is found but not assignable to the struct field, an error is returned.
 // It panics on error.
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"html/template"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -442,3 +443,73 @@ func castScopesToInjectors(scopes []*Scope) []Injector {
 		return item
 	})
 }
+
+/////////////////////////////////////////////////////////////////////////////
+// 							Slowest providers
+/////////////////////////////////////////////////////////////////////////////
+
+const explainSlowestProviderTemplate = `{{.Rank}}. {{.ServiceBuildTime}} - {{.ServiceName}} from scope {{.ScopeName}}`
+
+type ExplainSlowestProviderOutput struct {
+	ScopeID          string        `json:"scope_id"`
+	ScopeName        string        `json:"scope_name"`
+	ServiceName      string        `json:"service_name"`
+	ServiceBuildTime time.Duration `json:"service_build_time"`
+}
+
+type ExplainSlowestProvidersOutput []ExplainSlowestProviderOutput
+
+func (sp ExplainSlowestProvidersOutput) String() string {
+	if len(sp) == 0 {
+		return "Slowest providers:\n(none)"
+	}
+
+	lines := mAp(sp, func(item ExplainSlowestProviderOutput, i int) string {
+		return fromTemplate(
+			explainSlowestProviderTemplate,
+			map[string]string{
+				"Rank":             strconv.Itoa(i + 1),
+				"ScopeName":        item.ScopeName,
+				"ServiceName":      item.ServiceName,
+				"ServiceBuildTime": item.ServiceBuildTime.String(),
+			},
+		)
+	})
+
+	return "Slowest providers:\n" + strings.Join(lines, "\n")
+}
+
+// SlowestProviders returns the n built services having the longest build time, across the scope tree.
+// Build times include the construction of the dependencies.
+func (id *ExplainInjectorOutput) SlowestProviders(n int) ExplainSlowestProvidersOutput {
+	output := ExplainSlowestProvidersOutput{}
+
+	var walk func(scopes []ExplainInjectorScopeOutput)
+	walk = func(scopes []ExplainInjectorScopeOutput) {
+		for _, scope := range scopes {
+			for _, service := range scope.Services {
+				if service.ServiceBuildTime > 0 {
+					output = append(output, ExplainSlowestProviderOutput{
+						ScopeID:          scope.ScopeID,
+						ScopeName:        scope.ScopeName,
+						ServiceName:      service.ServiceName,
+						ServiceBuildTime: service.ServiceBuildTime,
+					})
+				}
+			}
+
+			walk(scope.Children)
+		}
+	}
+	walk(id.DAG)
+
+	sort.SliceStable(output, func(i, j int) bool {
+		return output[i].ServiceBuildTime > output[j].ServiceBuildTime
+	})
+
+	if n >= 0 && n < len(output) {
+		output = output[:n]
+	}
+
+	return output
+}
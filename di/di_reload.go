@@ -0,0 +1,209 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Reload rebuilds a lazy service, using type inference to determine the service name.
+func Reload[T any](i Injector) error {
+	name := inferServiceName[T]()
+	return ReloadNamedWithContext(context.Background(), i, name)
+}
+
+// ReloadWithContext rebuilds a lazy service, using type inference to determine the service name.
+func ReloadWithContext[T any](ctx context.Context, i Injector) error {
+	name := inferServiceName[T]()
+	return ReloadNamedWithContext(ctx, i, name)
+}
+
+// ReloadNamed rebuilds a named lazy service.
+func ReloadNamed(i Injector, name string) error {
+	return ReloadNamedWithContext(context.Background(), i, name)
+}
+
+// ReloadNamedWithContext rebuilds a named lazy service.
+// The provider is run again, the new instance replaces the previous one atomically,
+// and the previous instance is shut down gracefully. If the provider fails, the
+// previous instance is kept and the error is returned.
+func ReloadNamedWithContext(ctx context.Context, i Injector, name string) error {
+	return reloadNamed(ctx, i, name, false)
+}
+
+// ReloadCascade rebuilds a lazy service and its dependents, using type inference to determine the service name.
+func ReloadCascade[T any](i Injector) error {
+	name := inferServiceName[T]()
+	return ReloadNamedCascadeWithContext(context.Background(), i, name)
+}
+
+// ReloadCascadeWithContext rebuilds a lazy service and its dependents, using type inference to determine the service name.
+func ReloadCascadeWithContext[T any](ctx context.Context, i Injector) error {
+	name := inferServiceName[T]()
+	return ReloadNamedCascadeWithContext(ctx, i, name)
+}
+
+// ReloadNamedCascade rebuilds a named lazy service and its dependents.
+func ReloadNamedCascade(i Injector, name string) error {
+	return ReloadNamedCascadeWithContext(context.Background(), i, name)
+}
+
+// ReloadNamedCascadeWithContext rebuilds a named lazy service and its dependents.
+// Dependents are discovered from the DAG and reloaded after their own dependencies.
+// Dependents that are not lazy services are skipped.
+//
+// Every service is rebuilt before any previous instance is shut down, dependents first,
+// so that no service holds a dependency which has been shut down. When a provider fails,
+// the services reloaded so far are given back their previous instance, and the new
+// instances are shut down.
+func ReloadNamedCascadeWithContext(ctx context.Context, i Injector, name string) error {
+	return reloadNamed(ctx, i, name, true)
+}
+
+// MustReload rebuilds a lazy service, using type inference to determine the service name. It panics on error.
+func MustReload[T any](i Injector) {
+	must0(Reload[T](i))
+}
+
+// MustReloadNamed rebuilds a named lazy service. It panics on error.
+func MustReloadNamed(i Injector, name string) {
+	must0(ReloadNamed(i, name))
+}
+
+func reloadNamed(ctx context.Context, i Injector, name string, cascade bool) error {
+	injector := getInjectorOrDefault(i)
+
+	serviceAny, serviceScope, ok := injector.serviceGetRec(name)
+	if !ok {
+		return serviceNotFound(injector, ErrServiceNotFound, []string{name})
+	}
+
+	first, err := reloadService(serviceScope, name, serviceAny)
+	if err != nil {
+		return err
+	}
+	reloaded := []*reloadedService{first}
+
+	if cascade {
+		root := injector.RootScope()
+		for _, edge := range root.dag.orderedDependents(serviceScope.ID(), serviceScope.Name(), name) {
+			scope, ok := scopeByID(root, edge.ScopeID)
+			if !ok {
+				continue
+			}
+
+			dependent, ok := scope.serviceGet(edge.Service)
+			if !ok {
+				continue
+			}
+
+			if svc, ok := dependent.(serviceGetServiceType); !ok || svc.getServiceType() != ServiceTypeLazy {
+				continue
+			}
+
+			r, err := reloadService(scope, edge.Service, dependent)
+			if err != nil {
+				return rollbackReload(ctx, reloaded, err)
+			}
+			reloaded = append(reloaded, r)
+		}
+	}
+
+	// the previous instances are shut down once every service has been rebuilt, dependents first
+	errs := newShutdownErrors()
+	for j := len(reloaded) - 1; j >= 0; j-- {
+		r := reloaded[j]
+		errs.Add(r.scope.ID(), r.scope.Name(), r.name, r.commit(ctx))
+	}
+	if errs.Len() > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// reloadedInstance completes or undoes the reload of a service.
+type reloadedInstance struct {
+	// commit shuts down the previous instance.
+	commit func(ctx context.Context) error
+	// rollback restores the previous instance, and shuts down the new one.
+	rollback func(ctx context.Context) error
+}
+
+// reloadedService is a service rebuilt by a reload, along with its previous dependencies.
+type reloadedService struct {
+	reloadedInstance
+
+	scope                *Scope
+	name                 string
+	previousDependencies []EdgeService
+}
+
+// reloadService rebuilds a single service and refreshes its dependencies in the DAG.
+func reloadService(scope *Scope, name string, serviceAny any) (*reloadedService, error) {
+	service, ok := serviceAny.(serviceReload)
+	if !ok {
+		return nil, fmt.Errorf("%w `%s`", ErrServiceNotReloadable, name)
+	}
+
+	scope.logf("requested reload for service %s", name)
+
+	// The new instance might not have the same dependencies. They are recorded
+	// again while the provider is running.
+	dag := scope.RootScope().dag
+	previousDependencies := dag.removeDependencies(scope.ID(), scope.Name(), name)
+
+	instance, err := service.reload(&virtualScope{invokerChain: []string{name}, self: scope})
+	if err != nil {
+		// the previous instance is kept, along with its dependencies
+		restoreDependencies(scope, name, previousDependencies)
+		return nil, err
+	}
+
+	scope.onServiceInvoke(name)
+	scope.logf("reloaded service %s", name)
+
+	return &reloadedService{
+		reloadedInstance:     instance,
+		scope:                scope,
+		name:                 name,
+		previousDependencies: previousDependencies,
+	}, nil
+}
+
+// rollbackReload gives back their previous instance to the reloaded services, dependents first,
+// and returns err along with the errors of the shutdown of the new instances.
+func rollbackReload(ctx context.Context, reloaded []*reloadedService, err error) error {
+	errs := newShutdownErrors()
+	for j := len(reloaded) - 1; j >= 0; j-- {
+		r := reloaded[j]
+		errs.Add(r.scope.ID(), r.scope.Name(), r.name, r.rollback(ctx))
+
+		r.scope.RootScope().dag.removeDependencies(r.scope.ID(), r.scope.Name(), r.name)
+		restoreDependencies(r.scope, r.name, r.previousDependencies)
+		r.scope.logf("rolled back reload of service %s", r.name)
+	}
+
+	if errs.Len() > 0 {
+		return errors.Join(err, errs)
+	}
+
+	return err
+}
+
+// restoreDependencies records the dependencies of a service in the DAG.
+func restoreDependencies(scope *Scope, name string, dependencies []EdgeService) {
+	dag := scope.RootScope().dag
+	for _, dependency := range dependencies {
+		dag.addDependency(scope.ID(), scope.Name(), name, dependency.ScopeID, dependency.ScopeName, dependency.Service)
+	}
+}
+
+// scopeByID looks for a scope in the whole scope tree.
+func scopeByID(root *RootScope, id string) (*Scope, bool) {
+	if root.self.ID() == id {
+		return root.self, true
+	}
+
+	return root.ChildByID(id)
+}
@@ -0,0 +1,253 @@
+package di
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reloadTest struct {
+	version  int32
+	shutdown *int32
+}
+
+func (r *reloadTest) Shutdown(ctx context.Context) error {
+	atomic.AddInt32(r.shutdown, 1)
+	return nil
+}
+
+func TestReload(t *testing.T) {
+	is := assert.New(t)
+
+	var version int32
+	var shutdown int32
+
+	i := New()
+	Provide(i, func(i Injector) (*reloadTest, error) {
+		return &reloadTest{version: atomic.AddInt32(&version, 1), shutdown: &shutdown}, nil
+	})
+
+	// not built yet
+	is.Nil(Reload[*reloadTest](i))
+	is.Equal(int32(1), MustInvoke[*reloadTest](i).version)
+	is.Equal(int32(0), atomic.LoadInt32(&shutdown))
+
+	is.Nil(Reload[*reloadTest](i))
+	is.Equal(int32(2), MustInvoke[*reloadTest](i).version)
+	is.Equal(int32(1), atomic.LoadInt32(&shutdown))
+
+	MustReload[*reloadTest](i)
+	is.Equal(int32(3), MustInvoke[*reloadTest](i).version)
+	is.Equal(int32(2), atomic.LoadInt32(&shutdown))
+}
+
+func TestReloadNamed(t *testing.T) {
+	is := assert.New(t)
+
+	fail := false
+	counter := 0
+
+	i := New()
+	ProvideNamedValue(i, "config", 42)
+	ProvideNamed(i, "a", func(i Injector) (int, error) {
+		if fail {
+			return 0, assert.AnError
+		}
+
+		counter++
+		return MustInvokeNamed[int](i, "config") + counter, nil
+	})
+
+	is.Equal(43, MustInvokeNamed[int](i, "a"))
+
+	// keep previous instance on error
+	fail = true
+	is.ErrorIs(ReloadNamed(i, "a"), assert.AnError)
+	is.Equal(43, MustInvokeNamed[int](i, "a"))
+
+	dependencies, _ := i.dag.explainService(i.ID(), i.Name(), "a")
+	is.Equal([]EdgeService{newEdgeService(i.ID(), i.Name(), "config")}, dependencies)
+
+	fail = false
+	is.Nil(ReloadNamed(i, "a"))
+	is.Equal(44, MustInvokeNamed[int](i, "a"))
+
+	dependencies, _ = i.dag.explainService(i.ID(), i.Name(), "a")
+	is.Equal([]EdgeService{newEdgeService(i.ID(), i.Name(), "config")}, dependencies)
+
+	// errors
+	is.ErrorIs(ReloadNamed(i, "config"), ErrServiceNotReloadable)
+	is.ErrorIs(ReloadNamed(i, "not-found"), ErrServiceNotFound)
+	is.Panics(func() {
+		MustReloadNamed(i, "not-found")
+	})
+}
+
+func TestReloadNamedCascade(t *testing.T) {
+	is := assert.New(t)
+
+	builds := []string{}
+
+	i := New()
+	child := i.Scope("child")
+
+	ProvideNamed(i, "config", func(i Injector) (int, error) {
+		builds = append(builds, "config")
+		return len(builds), nil
+	})
+	ProvideNamed(i, "db", func(i Injector) (int, error) {
+		builds = append(builds, "db")
+		return MustInvokeNamed[int](i, "config"), nil
+	})
+	ProvideNamed(child, "repository", func(i Injector) (int, error) {
+		builds = append(builds, "repository")
+		return MustInvokeNamed[int](i, "db") + MustInvokeNamed[int](i, "config"), nil
+	})
+	ProvideNamed(i, "unrelated", func(i Injector) (int, error) {
+		builds = append(builds, "unrelated")
+		return 0, nil
+	})
+	ProvideNamedTransient(child, "handler", func(i Injector) (int, error) {
+		return MustInvokeNamed[int](i, "repository"), nil
+	})
+
+	_ = MustInvokeNamed[int](child, "handler")
+	_ = MustInvokeNamed[int](i, "unrelated")
+	is.Equal([]string{"repository", "db", "config", "unrelated"}, builds)
+
+	builds = []string{}
+
+	// without cascade
+	is.Nil(ReloadNamed(i, "config"))
+	is.Equal([]string{"config"}, builds)
+
+	builds = []string{}
+
+	// with cascade: dependencies are reloaded before dependents
+	is.Nil(ReloadNamedCascade(i, "config"))
+	is.Equal([]string{"config", "db", "repository"}, builds)
+	is.Equal(2, MustInvokeNamed[int](child, "repository"))
+}
+
+type reloadNode struct {
+	name   string
+	deps   []*reloadNode
+	closed bool
+	closes *[]string
+}
+
+func (n *reloadNode) Shutdown() {
+	n.closed = true
+	*n.closes = append(*n.closes, n.name)
+}
+
+func TestReloadNamedCascade_ProviderError(t *testing.T) {
+	is := assert.New(t)
+
+	created := []*reloadNode{}
+	closes := []string{}
+	failHandler := false
+
+	i := New()
+	provide := func(name string, deps ...string) {
+		ProvideNamed(i, name, func(i Injector) (*reloadNode, error) {
+			if name == "handler" && failHandler {
+				return nil, assert.AnError
+			}
+
+			node := &reloadNode{name: name, closes: &closes}
+			for _, dep := range deps {
+				node.deps = append(node.deps, MustInvokeNamed[*reloadNode](i, dep))
+			}
+			created = append(created, node)
+			return node, nil
+		})
+	}
+	provide("db")
+	provide("repository", "db")
+	provide("handler", "repository")
+
+	handler := MustInvokeNamed[*reloadNode](i, "handler")
+	repository := handler.deps[0]
+	db := repository.deps[0]
+	is.Len(created, 3)
+
+	// the handler fails: db and repository get their previous instance back,
+	// and the new ones are shut down
+	failHandler = true
+	is.ErrorIs(ReloadNamedCascade(i, "db"), assert.AnError)
+	is.Len(created, 5)
+	is.Same(db, MustInvokeNamed[*reloadNode](i, "db"))
+	is.Same(repository, MustInvokeNamed[*reloadNode](i, "repository"))
+	is.Same(handler, MustInvokeNamed[*reloadNode](i, "handler"))
+	is.False(db.closed)
+	is.False(repository.closed)
+	is.True(created[3].closed)
+	is.True(created[4].closed)
+	is.Equal([]string{"repository", "db"}, closes)
+
+	dependencies, _ := i.dag.explainService(i.ID(), i.Name(), "repository")
+	is.Equal([]EdgeService{newEdgeService(i.ID(), i.Name(), "db")}, dependencies)
+
+	// every service is rebuilt before the previous instances are shut down, dependents first
+	failHandler = false
+	closes = []string{}
+	is.Nil(ReloadNamedCascade(i, "db"))
+	is.Equal([]string{"handler", "repository", "db"}, closes)
+	is.True(handler.closed)
+	is.True(repository.closed)
+	is.True(db.closed)
+
+	newHandler := MustInvokeNamed[*reloadNode](i, "handler")
+	is.NotSame(handler, newHandler)
+	is.False(newHandler.closed)
+	is.False(newHandler.deps[0].closed)
+	is.False(newHandler.deps[0].deps[0].closed)
+	is.Same(MustInvokeNamed[*reloadNode](i, "db"), newHandler.deps[0].deps[0])
+}
+
+func TestDAG_orderedDependents(t *testing.T) {
+	is := assert.New(t)
+
+	dag := newDAG()
+
+	// a <- b <- c
+	// a <- c
+	// d <- e <- d (cycle)
+	dag.addDependency("scope", "scope", "b", "scope", "scope", "a")
+	dag.addDependency("scope", "scope", "c", "scope", "scope", "b")
+	dag.addDependency("scope", "scope", "c", "scope", "scope", "a")
+	dag.addDependency("scope", "scope", "d", "scope", "scope", "a")
+	dag.addDependency("scope", "scope", "e", "scope", "scope", "d")
+	dag.addDependency("scope", "scope", "d", "scope", "scope", "e")
+
+	is.Equal(
+		[]EdgeService{
+			newEdgeService("scope", "scope", "b"),
+			newEdgeService("scope", "scope", "c"),
+			newEdgeService("scope", "scope", "d"),
+			newEdgeService("scope", "scope", "e"),
+		},
+		dag.orderedDependents("scope", "scope", "a"),
+	)
+	is.Empty(dag.orderedDependents("scope", "scope", "c"))
+
+	removed := dag.removeDependencies("scope", "scope", "c")
+	is.ElementsMatch(
+		[]EdgeService{
+			newEdgeService("scope", "scope", "a"),
+			newEdgeService("scope", "scope", "b"),
+		},
+		removed,
+	)
+	is.Equal(
+		[]EdgeService{
+			newEdgeService("scope", "scope", "b"),
+			newEdgeService("scope", "scope", "d"),
+			newEdgeService("scope", "scope", "e"),
+		},
+		dag.orderedDependents("scope", "scope", "a"),
+	)
+}
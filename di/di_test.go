@@ -576,7 +576,7 @@ func TestInvokeStruct(t *testing.T) {
 
 	// exported field - generic type
 	type hasExportedEagerTestDependency struct {
-		EagerTest *eagerTest `do:""`
+		EagerTest *eagerTest `di:""`
 	}
 	test2, err := InvokeStruct[hasExportedEagerTestDependency](i)
 	is.Nil(err)
@@ -584,7 +584,7 @@ func TestInvokeStruct(t *testing.T) {
 
 	// unexported field
 	type hasNonExportedEagerTestDependency struct {
-		eagerTest *eagerTest `do:""`
+		eagerTest *eagerTest `di:""`
 	}
 	test3, err := InvokeStruct[hasNonExportedEagerTestDependency](i)
 	is.Nil(err)
@@ -592,7 +592,7 @@ func TestInvokeStruct(t *testing.T) {
 
 	// not found
 	type dependencyNotFound struct {
-		eagerTest *hasNonExportedEagerTestDependency `do:""` //nolint:unused
+		eagerTest *hasNonExportedEagerTestDependency `di:""` //nolint:unused
 	}
 	test4, err := InvokeStruct[dependencyNotFound](i)
 	is.Equal(serviceNotFound(i, ErrServiceNotFound, []string{inferServiceName[*hasNonExportedEagerTestDependency]()}).Error(), err.Error())
@@ -600,7 +600,7 @@ func TestInvokeStruct(t *testing.T) {
 
 	// use tag
 	type namedDependency struct {
-		eagerTest *eagerTest `do:"int"` //nolint:unused
+		eagerTest *eagerTest `di:"int"` //nolint:unused
 	}
 	test5, err := InvokeStruct[namedDependency](i)
 	is.Equal(serviceNotFound(i, ErrServiceNotFound, []string{inferServiceName[int]()}).Error(), err.Error())
@@ -609,7 +609,7 @@ func TestInvokeStruct(t *testing.T) {
 	// named service
 	ProvideNamedValue(i, "foobar", 42)
 	type namedService struct {
-		EagerTest int `do:"foobar"`
+		EagerTest int `di:"foobar"`
 	}
 	test6, err := InvokeStruct[namedService](i)
 	is.Nil(err)
@@ -617,7 +617,7 @@ func TestInvokeStruct(t *testing.T) {
 
 	// use tag but wrong type
 	type namedDependencyButTypeMismatch struct {
-		EagerTest *int `do:"*github.com/sllt/af/di.eagerTest"`
+		EagerTest *int `di:"*github.com/sllt/af/di.eagerTest"`
 	}
 	test7, err := InvokeStruct[namedDependencyButTypeMismatch](i)
 	is.Equal("DI: field `github.com/sllt/af/di.namedDependencyButTypeMismatch.EagerTest` is not assignable to service *github.com/sllt/af/di.eagerTest", err.Error())
@@ -640,7 +640,7 @@ func TestMustInvokeStruct(t *testing.T) {
 
 	// use a custom tag
 	type namedServiceWithCustomTag struct {
-		EagerTest int `do:"foobar"`
+		EagerTest int `di:"foobar"`
 	}
 
 	is.Panics(func() {
@@ -0,0 +1,254 @@
+package di
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InvocationEvent describes a single service invocation. It is sent to the
+// invocation hooks once the service instance has been retrieved (or failed).
+type InvocationEvent struct {
+	ScopeID   string
+	ScopeName string
+	Service   string
+
+	// Parent is the service that invoked this one, or an empty string
+	// when the service has been invoked directly from the injector.
+	Parent string
+	// Chain is the full resolution chain, from the first invoked service
+	// down to the current one (included).
+	Chain []string
+
+	Start    time.Time
+	Duration time.Duration
+	// Built reports whether the invocation ran the provider. It is false when
+	// the instance was already available (eager values, built lazy services).
+	// Concurrent first invocations of a lazy service may all report true.
+	Built bool
+	Err   error
+}
+
+// startInvocationTrace records the start of an invocation. The returned callback
+// must be called when the invocation ends, in order to send the event to the hooks.
+func startInvocationTrace(injector Injector, serviceScope *Scope, name string, invokerChain []string, service any) func(error) {
+	opts := injector.RootScope().opts
+	if len(opts.HookInvocation) == 0 {
+		return func(error) {}
+	}
+
+	built := serviceIsBuilt(service)
+	chain := append([]string{}, invokerChain...)
+	start := time.Now()
+
+	return func(err error) {
+		parent := ""
+		if len(chain) > 1 {
+			parent = chain[len(chain)-2]
+		}
+
+		opts.onInvocation(InvocationEvent{
+			ScopeID:   serviceScope.ID(),
+			ScopeName: serviceScope.Name(),
+			Service:   name,
+			Parent:    parent,
+			Chain:     chain,
+			Start:     start,
+			Duration:  time.Since(start),
+			Built:     !built,
+			Err:       err,
+		})
+	}
+}
+
+// serviceIsBuilt returns true when invoking the service would not run a provider.
+// An alias is built when its target is.
+func serviceIsBuilt(service any) bool {
+	if alias, ok := service.(serviceGetTarget); ok {
+		target, found := alias.getTarget()
+		return !found || serviceIsBuilt(target)
+	}
+
+	if svc, ok := service.(serviceGetServiceType); ok && svc.getServiceType() == ServiceTypeTransient {
+		return false
+	}
+
+	if lazy, ok := service.(serviceBuildTime); ok {
+		_, built := lazy.getBuildTime()
+		return built
+	}
+
+	return true
+}
+
+// NewInvocationCollector creates a collector of invocation events.
+// It must be registered on the injector with:
+//
+//	injector.AddInvocationHook(collector.Collect)
+func NewInvocationCollector() *InvocationCollector {
+	return &InvocationCollector{
+		mu:     sync.Mutex{},
+		events: []InvocationEvent{},
+	}
+}
+
+// InvocationCollector records invocation events, in order to report
+// the startup timeline of an injector.
+type InvocationCollector struct {
+	mu     sync.Mutex
+	events []InvocationEvent
+}
+
+// Collect records an invocation event. It is meant to be used as an invocation hook.
+func (c *InvocationCollector) Collect(event InvocationEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(c.events, event)
+}
+
+// Events returns the recorded events, ordered by start time.
+func (c *InvocationCollector) Events() []InvocationEvent {
+	c.mu.Lock()
+	events := append([]InvocationEvent{}, c.events...)
+	c.mu.Unlock()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	return events
+}
+
+// Reset drops the recorded events.
+func (c *InvocationCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = []InvocationEvent{}
+}
+
+// Slowest returns the n invocations that took the longest time to build a service.
+// Durations include the construction of the dependencies.
+func (c *InvocationCollector) Slowest(n int) []InvocationEvent {
+	events := filter(c.Events(), func(item InvocationEvent, _ int) bool {
+		return item.Built
+	})
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Duration > events[j].Duration
+	})
+
+	if n >= 0 && n < len(events) {
+		events = events[:n]
+	}
+
+	return events
+}
+
+// Folded returns the built services in the "folded stacks" format, one line per
+// resolution chain, with the self time in microseconds: the time spent building the
+// service, minus the time spent building its dependencies. The output can be piped
+// to flame graph tools, which add the dependencies back into the width of their parent.
+func (c *InvocationCollector) Folded() string {
+	lines := mAp(c.flameNodes(), func(node *invocationFlameNode, _ int) string {
+		return fmt.Sprintf("%s %d", strings.Join(node.chain, ";"), node.self.Microseconds())
+	})
+
+	return strings.Join(lines, "\n")
+}
+
+// FlameChart returns a human readable timeline of the built services,
+// where each service is nested under the service that invoked it.
+func (c *InvocationCollector) FlameChart() string {
+	const barWidth = 40
+
+	nodes := c.flameNodes()
+	if len(nodes) == 0 {
+		return "DI: no service built"
+	}
+
+	var total time.Duration
+	nameWidth := 0
+	for _, node := range nodes {
+		if len(node.chain) == 1 {
+			total += node.duration
+		}
+		if w := 2*(len(node.chain)-1) + len(node.chain[len(node.chain)-1]); w > nameWidth {
+			nameWidth = w
+		}
+	}
+
+	lines := []string{fmt.Sprintf("DI: startup flame chart (total: %s)", total)}
+	for _, node := range nodes {
+		width := barWidth
+		if total > 0 {
+			width = int(int64(barWidth) * int64(node.duration) / int64(total))
+		}
+		if width < 1 {
+			width = 1
+		}
+
+		label := strings.Repeat("  ", len(node.chain)-1) + node.chain[len(node.chain)-1]
+		lines = append(lines, fmt.Sprintf("%-*s %s %s", nameWidth, label, strings.Repeat("█", width), node.duration))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type invocationFlameNode struct {
+	chain    []string
+	start    time.Time
+	duration time.Duration
+	// self is the duration minus the duration of the direct children.
+	self time.Duration
+}
+
+// flameNodes merges built invocations by resolution chain, in depth-first order.
+func (c *InvocationCollector) flameNodes() []*invocationFlameNode {
+	nodes := map[string]*invocationFlameNode{}
+	children := map[string][]*invocationFlameNode{}
+
+	for _, event := range c.Events() {
+		if !event.Built {
+			continue
+		}
+
+		key := strings.Join(event.Chain, ";")
+		if node, ok := nodes[key]; ok {
+			node.duration += event.Duration
+			continue
+		}
+
+		node := &invocationFlameNode{chain: event.Chain, start: event.Start, duration: event.Duration}
+		nodes[key] = node
+
+		parentKey := strings.Join(event.Chain[:len(event.Chain)-1], ";")
+		children[parentKey] = append(children[parentKey], node)
+	}
+
+	output := []*invocationFlameNode{}
+
+	var walk func(key string)
+	walk = func(key string) {
+		for _, node := range children[key] {
+			output = append(output, node)
+
+			nodeKey := strings.Join(node.chain, ";")
+			node.self = node.duration
+			for _, child := range children[nodeKey] {
+				node.self -= child.duration
+			}
+			if node.self < 0 {
+				node.self = 0
+			}
+
+			walk(nodeKey)
+		}
+	}
+	walk("")
+
+	return output
+}
@@ -0,0 +1,225 @@
+package di
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvocationHook(t *testing.T) {
+	is := assert.New(t)
+
+	events := []InvocationEvent{}
+
+	i := NewWithOpts(&InjectorOpts{
+		HookInvocation: []func(InvocationEvent){
+			func(event InvocationEvent) { events = append(events, event) },
+		},
+	})
+
+	ProvideNamed(i, "a", func(i Injector) (int, error) {
+		return MustInvokeNamed[int](i, "b") + 1, nil
+	})
+	ProvideNamed(i, "b", func(i Injector) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	})
+	ProvideNamed(i, "c", func(i Injector) (int, error) {
+		return 0, assert.AnError
+	})
+
+	is.Equal(2, MustInvokeNamed[int](i, "a"))
+	is.Len(events, 2)
+
+	// dependency is reported first
+	is.Equal("b", events[0].Service)
+	is.Equal("a", events[0].Parent)
+	is.Equal([]string{"a", "b"}, events[0].Chain)
+	is.Equal(i.ID(), events[0].ScopeID)
+	is.Equal(i.Name(), events[0].ScopeName)
+	is.True(events[0].Built)
+	is.Nil(events[0].Err)
+	is.GreaterOrEqual(events[0].Duration, 10*time.Millisecond)
+
+	is.Equal("a", events[1].Service)
+	is.Equal("", events[1].Parent)
+	is.Equal([]string{"a"}, events[1].Chain)
+	is.True(events[1].Built)
+	is.GreaterOrEqual(events[1].Duration, events[0].Duration)
+
+	// already built
+	_ = MustInvokeNamed[int](i, "a")
+	is.Len(events, 3)
+	is.False(events[2].Built)
+
+	// error
+	_, err := InvokeNamed[int](i, "c")
+	is.ErrorIs(err, assert.AnError)
+	is.Len(events, 4)
+	is.ErrorIs(events[3].Err, assert.AnError)
+}
+
+func TestInvocationCollector(t *testing.T) {
+	is := assert.New(t)
+
+	collector := NewInvocationCollector()
+
+	i := New()
+	i.AddInvocationHook(collector.Collect)
+
+	ProvideNamed(i, "a", func(i Injector) (int, error) {
+		return MustInvokeNamed[int](i, "b") + MustInvokeNamed[int](i, "c"), nil
+	})
+	ProvideNamed(i, "b", func(i Injector) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 1, nil
+	})
+	ProvideNamed(i, "c", func(i Injector) (int, error) {
+		return MustInvokeNamed[int](i, "b"), nil
+	})
+	ProvideNamedValue(i, "d", 42)
+
+	_ = MustInvokeNamed[int](i, "a")
+	_ = MustInvokeNamed[int](i, "d")
+
+	is.Len(collector.Events(), 5)
+
+	slowest := collector.Slowest(2)
+	is.Len(slowest, 2)
+	is.Equal("a", slowest[0].Service)
+	is.Equal("b", slowest[1].Service)
+
+	folded := strings.Split(collector.Folded(), "\n")
+	is.Len(folded, 3)
+	is.True(strings.HasPrefix(folded[0], "a "))
+	is.True(strings.HasPrefix(folded[1], "a;b "))
+	is.True(strings.HasPrefix(folded[2], "a;c "))
+
+	chart := strings.Split(collector.FlameChart(), "\n")
+	is.Len(chart, 4)
+	is.True(strings.HasPrefix(chart[0], "DI: startup flame chart (total: "))
+	is.True(strings.HasPrefix(chart[1], "a "))
+	is.True(strings.HasPrefix(chart[2], "  b "))
+	is.True(strings.HasPrefix(chart[3], "  c "))
+
+	collector.Reset()
+	is.Empty(collector.Events())
+	is.Equal("DI: no service built", collector.FlameChart())
+}
+
+func TestInvocationCollector_FoldedSelfTime(t *testing.T) {
+	is := assert.New(t)
+
+	collector := NewInvocationCollector()
+
+	i := New()
+	i.AddInvocationHook(collector.Collect)
+
+	ProvideNamed(i, "a", func(i Injector) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return MustInvokeNamed[int](i, "b"), nil
+	})
+	ProvideNamed(i, "b", func(i Injector) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return MustInvokeNamed[int](i, "c"), nil
+	})
+	ProvideNamed(i, "c", func(i Injector) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	})
+
+	_ = MustInvokeNamed[int](i, "a")
+
+	events := collector.Events()
+	is.Len(events, 3)
+	root := events[0]
+	is.Equal("a", root.Service)
+
+	folded := strings.Split(collector.Folded(), "\n")
+	is.Len(folded, 3)
+
+	// the self times add up to the duration of the root, each line losing less than 1µs when rounded
+	var total int64
+	for _, line := range folded {
+		parts := strings.Split(line, " ")
+		is.Len(parts, 2)
+		self, err := strconv.ParseInt(parts[1], 10, 64)
+		is.NoError(err)
+		is.GreaterOrEqual(self, (10 * time.Millisecond).Microseconds())
+		is.Less(self, root.Duration.Microseconds()/2)
+		total += self
+	}
+	is.LessOrEqual(total, root.Duration.Microseconds())
+	is.GreaterOrEqual(total, root.Duration.Microseconds()-int64(len(folded)))
+}
+
+func TestInvocationHook_Alias(t *testing.T) {
+	is := assert.New(t)
+
+	events := []InvocationEvent{}
+
+	i := NewWithOpts(&InjectorOpts{
+		HookInvocation: []func(InvocationEvent){
+			func(event InvocationEvent) { events = append(events, event) },
+		},
+	})
+
+	Provide(i, func(i Injector) (*lazyTestHeathcheckerOK, error) { return &lazyTestHeathcheckerOK{}, nil })
+	is.Nil(As[*lazyTestHeathcheckerOK, Healthchecker](i))
+
+	_ = MustInvoke[Healthchecker](i)
+	is.NotEmpty(events)
+	is.True(events[len(events)-1].Built)
+
+	events = events[:0]
+	_ = MustInvoke[Healthchecker](i)
+	is.NotEmpty(events)
+	for _, event := range events {
+		is.False(event.Built)
+	}
+}
+
+func TestExplainInjector_SlowestProviders(t *testing.T) {
+	is := assert.New(t)
+
+	i := New()
+	child := i.Scope("child")
+
+	ProvideNamed(i, "SERVICE-A", func(i Injector) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	})
+	ProvideNamed(child, "SERVICE-B", func(i Injector) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return MustInvokeNamed[int](i, "SERVICE-A"), nil
+	})
+	ProvideNamed(child, "SERVICE-C", func(i Injector) (int, error) {
+		return 3, nil
+	})
+	ProvideNamedValue(i, "SERVICE-D", 4)
+
+	output := ExplainInjector(i)
+	is.Empty(output.SlowestProviders(10))
+	is.Equal("Slowest providers:\n(none)", output.SlowestProviders(10).String())
+
+	_ = MustInvokeNamed[int](child, "SERVICE-B")
+
+	output = ExplainInjector(i)
+	slowest := output.SlowestProviders(2)
+	is.Len(slowest, 2)
+	is.Equal("SERVICE-B", slowest[0].ServiceName)
+	is.Equal("child", slowest[0].ScopeName)
+	is.GreaterOrEqual(slowest[0].ServiceBuildTime, 30*time.Millisecond)
+	is.Equal("SERVICE-A", slowest[1].ServiceName)
+	is.Equal("[root]", slowest[1].ScopeName)
+
+	lines := strings.Split(slowest.String(), "\n")
+	is.Len(lines, 3)
+	is.Equal("Slowest providers:", lines[0])
+	is.True(strings.HasPrefix(lines[1], "1. "))
+	is.True(strings.HasSuffix(lines[1], " - SERVICE-B from scope child"))
+	is.True(strings.HasSuffix(lines[2], " - SERVICE-A from scope [root]"))
+}
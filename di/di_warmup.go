@@ -0,0 +1,129 @@
+package di
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Warmup builds every lazy service available from the scope and its ancestors, concurrently.
+// It returns the construction time of each service, and the errors of the services that failed to build.
+func Warmup(i Injector) (map[EdgeService]time.Duration, *WarmupErrors) {
+	return WarmupWithContext(context.Background(), i)
+}
+
+// WarmupWithContext builds every lazy service available from the scope and its ancestors, concurrently.
+// Services that have not started building when the context is done are skipped and reported with ctx.Err().
+func WarmupWithContext(ctx context.Context, i Injector) (map[EdgeService]time.Duration, *WarmupErrors) {
+	injector := getInjectorOrDefault(i)
+
+	names := []string{}
+	injector.serviceForEachRec(func(name string, _ *Scope, service any) bool {
+		if svc, ok := service.(serviceGetServiceType); ok && svc.getServiceType() == ServiceTypeLazy {
+			names = append(names, name)
+		}
+		return true
+	})
+
+	return InvokeAllNamedWithContext(ctx, injector, names...)
+}
+
+// InvokeAllNamed invokes many named services concurrently.
+// The parallelism is bounded by InjectorOpts.WarmupParallelism.
+// It returns the construction time of each service, and the errors of the services that failed to build.
+func InvokeAllNamed(i Injector, names ...string) (map[EdgeService]time.Duration, *WarmupErrors) {
+	return InvokeAllNamedWithContext(context.Background(), i, names...)
+}
+
+// InvokeAllNamedWithContext invokes many named services concurrently.
+// The parallelism is bounded by InjectorOpts.WarmupParallelism.
+// Services that have not started building when the context is done are skipped and reported with ctx.Err().
+//
+// A service is never built twice: duplicated names are invoked once, and concurrent
+// invocations of a shared dependency wait for the first construction to complete.
+func InvokeAllNamedWithContext(ctx context.Context, i Injector, names ...string) (map[EdgeService]time.Duration, *WarmupErrors) {
+	injector := getInjectorOrDefault(i)
+	opts := injector.RootScope().opts
+
+	names = orderedUniq(names)
+	// order by name to have a deterministic scheduling in unit tests
+	sort.Strings(names)
+
+	injector.RootScope().opts.Logf("DI: warming up %d services", len(names))
+
+	mu := sync.Mutex{}
+	buildTimes := map[EdgeService]time.Duration{}
+	errs := newWarmupErrors()
+
+	invoke := func(name string) {
+		edge := newEdgeService(injector.ID(), injector.Name(), name)
+		serviceAny, serviceScope, found := injector.serviceGetRec(name)
+		if found {
+			edge = newEdgeService(serviceScope.ID(), serviceScope.Name(), name)
+		}
+
+		var buildTime time.Duration
+		err := ctx.Err()
+		if err == nil {
+			start := time.Now()
+			_, err = invokeAnyByName(injector, name)
+			buildTime = time.Since(start)
+
+			// prefer the time spent in the provider, when the service has been built by another invocation
+			if lazy, ok := serviceAny.(serviceBuildTime); ok {
+				if d, built := lazy.getBuildTime(); built {
+					buildTime = d
+				}
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			errs.Add(edge.ScopeID, edge.ScopeName, edge.Service, err)
+		} else {
+			buildTimes[edge] = buildTime
+		}
+	}
+
+	if opts.WarmupParallelism == 0 {
+		var wg sync.WaitGroup
+		wg.Add(len(names))
+
+		for _, name := range names {
+			go func(n string) {
+				defer wg.Done()
+				invoke(n)
+			}(name)
+		}
+
+		wg.Wait()
+	} else {
+		pool := newJobPool[struct{}](opts.WarmupParallelism)
+		pool.start()
+		defer pool.stop()
+
+		results := make([]<-chan struct{}, 0, len(names))
+		for _, name := range names {
+			n := name
+			results = append(results, pool.rpc(func() struct{} {
+				invoke(n)
+				return struct{}{}
+			}))
+		}
+
+		for _, result := range results {
+			<-result
+		}
+	}
+
+	injector.RootScope().opts.Logf("DI: warmed up %d services", len(buildTimes))
+
+	if errs.Len() > 0 {
+		return buildTimes, errs
+	}
+
+	return buildTimes, nil
+}
@@ -0,0 +1,165 @@
+package di
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmup(t *testing.T) {
+	testWithTimeout(t, 100*time.Millisecond)
+	is := assert.New(t)
+
+	i := New()
+
+	ProvideNamed(i, "a", func(i Injector) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 1, nil
+	})
+	ProvideNamed(i, "b", func(i Injector) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 2, nil
+	})
+	ProvideNamed(i, "c", func(i Injector) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 3, nil
+	})
+	ProvideNamedValue(i, "d", 4)
+	ProvideNamedTransient(i, "e", func(i Injector) (int, error) {
+		return 5, nil
+	})
+
+	start := time.Now()
+	buildTimes, err := Warmup(i)
+	is.Nil(err)
+	is.Less(time.Since(start), 50*time.Millisecond) // built in parallel
+
+	is.Len(buildTimes, 3)
+	for _, name := range []string{"a", "b", "c"} {
+		d, ok := buildTimes[newEdgeService(i.ID(), i.Name(), name)]
+		is.True(ok)
+		is.GreaterOrEqual(d, 20*time.Millisecond)
+	}
+
+	is.ElementsMatch(
+		[]EdgeService{
+			newEdgeService(i.ID(), i.Name(), "a"),
+			newEdgeService(i.ID(), i.Name(), "b"),
+			newEdgeService(i.ID(), i.Name(), "c"),
+		},
+		i.ListInvokedServices(),
+	)
+}
+
+func TestWarmup_scopes(t *testing.T) {
+	is := assert.New(t)
+
+	i := New()
+	child := i.Scope("child")
+
+	ProvideNamed(i, "a", func(i Injector) (int, error) { return 1, nil })
+	ProvideNamed(child, "b", func(i Injector) (int, error) { return 2, nil })
+
+	buildTimes, err := Warmup(child)
+	is.Nil(err)
+	is.Len(buildTimes, 2)
+	is.Contains(buildTimes, newEdgeService(i.ID(), i.Name(), "a"))
+	is.Contains(buildTimes, newEdgeService(child.ID(), child.Name(), "b"))
+}
+
+func TestInvokeAllNamed(t *testing.T) {
+	testWithTimeout(t, 100*time.Millisecond)
+	is := assert.New(t)
+
+	i := New()
+
+	var counter int32
+	ProvideNamed(i, "shared", func(i Injector) (int, error) {
+		atomic.AddInt32(&counter, 1)
+		time.Sleep(10 * time.Millisecond)
+		return 0, nil
+	})
+	ProvideNamed(i, "a", func(i Injector) (int, error) {
+		return MustInvokeNamed[int](i, "shared") + 1, nil
+	})
+	ProvideNamed(i, "b", func(i Injector) (int, error) {
+		return MustInvokeNamed[int](i, "shared") + 2, nil
+	})
+	ProvideNamed(i, "c", func(i Injector) (int, error) {
+		return 0, assert.AnError
+	})
+
+	buildTimes, err := InvokeAllNamed(i, "a", "b", "b", "c", "not-found")
+	is.Len(buildTimes, 2)
+	is.NotNil(err)
+	is.Equal(2, err.Len())
+	is.ErrorIs((*err)[newEdgeService(i.ID(), i.Name(), "c")], assert.AnError)
+	is.ErrorIs((*err)[newEdgeService(i.ID(), i.Name(), "not-found")], ErrServiceNotFound)
+
+	// shared dependency is built once
+	is.Equal(int32(1), atomic.LoadInt32(&counter))
+
+	// DAG is consistent
+	_, dependents := i.dag.explainService(i.ID(), i.Name(), "shared")
+	is.ElementsMatch(
+		[]EdgeService{
+			newEdgeService(i.ID(), i.Name(), "a"),
+			newEdgeService(i.ID(), i.Name(), "b"),
+		},
+		dependents,
+	)
+}
+
+func TestInvokeAllNamed_parallelism(t *testing.T) {
+	testWithTimeout(t, 200*time.Millisecond)
+	is := assert.New(t)
+
+	i := NewWithOpts(&InjectorOpts{
+		WarmupParallelism: 2,
+	})
+
+	var running int32
+	var maxRunning int32
+	provider := func(i Injector) (int, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return 42, nil
+	}
+
+	ProvideNamed(i, "a", provider)
+	ProvideNamed(i, "b", provider)
+	ProvideNamed(i, "c", provider)
+	ProvideNamed(i, "d", provider)
+
+	buildTimes, err := InvokeAllNamed(i, "a", "b", "c", "d")
+	is.Nil(err)
+	is.Len(buildTimes, 4)
+	is.Equal(int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+func TestInvokeAllNamedWithContext(t *testing.T) {
+	is := assert.New(t)
+
+	i := New()
+	ProvideNamed(i, "a", func(i Injector) (int, error) { return 1, nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	buildTimes, err := InvokeAllNamedWithContext(ctx, i, "a")
+	is.Len(buildTimes, 0)
+	is.NotNil(err)
+	is.ErrorIs((*err)[newEdgeService(i.ID(), i.Name(), "a")], context.Canceled)
+	is.Empty(i.ListInvokedServices())
+}
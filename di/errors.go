@@ -10,6 +10,7 @@ var ErrServiceNotFound = errors.New("DI: could not find service")
 var ErrServiceNotMatch = errors.New("DI: could not find service satisfying interface")
 var ErrCircularDependency = errors.New("DI: circular dependency detected")
 var ErrHealthCheckTimeout = errors.New("DI: health check timeout")
+var ErrServiceNotReloadable = errors.New("DI: service is not reloadable")
 
 func newShutdownErrors() *ShutdownErrors {
 	return &ShutdownErrors{}
@@ -64,3 +65,40 @@ func mergeShutdownErrors(ins ...*ShutdownErrors) *ShutdownErrors {
 
 	return out
 }
+
+func newWarmupErrors() *WarmupErrors {
+	return &WarmupErrors{}
+}
+
+type WarmupErrors map[EdgeService]error
+
+func (e *WarmupErrors) Add(scopeID string, scopeName string, serviceName string, err error) {
+	if err != nil {
+		(*e)[newEdgeService(scopeID, scopeName, serviceName)] = err
+	}
+}
+
+func (e WarmupErrors) Len() int {
+	out := 0
+	for _, v := range e {
+		if v != nil {
+			out++
+		}
+	}
+	return out
+}
+
+func (e WarmupErrors) Error() string {
+	lines := []string{}
+	for k, v := range e {
+		if v != nil {
+			lines = append(lines, fmt.Sprintf("  - %s > %s: %s", k.ScopeName, k.Service, v.Error()))
+		}
+	}
+
+	if len(lines) == 0 {
+		return "DI: no warmup errors"
+	}
+
+	return "DI: warmup errors:\n" + strings.Join(lines, "\n")
+}
@@ -65,3 +65,18 @@ func TestMergeShutdownErrors(t *testing.T) {
 		result,
 	)
 }
+
+func TestWarmupErrors_Error(t *testing.T) {
+	is := assert.New(t)
+
+	we := newWarmupErrors()
+	is.Equal(0, we.Len())
+	is.EqualValues("DI: no warmup errors", we.Error())
+
+	we.Add("scope-1", "scope-a", "service-a", nil)
+	is.Equal(0, we.Len())
+
+	we.Add("scope-2", "scope-b", "service-b", assert.AnError)
+	is.Equal(1, we.Len())
+	is.EqualValues("DI: warmup errors:\n  - scope-b > service-b: assert.AnError general error for testing", we.Error())
+}
@@ -40,9 +40,11 @@ func invokeAnyByName(i Injector, name string) (any, error) {
 		return nil, serviceNotFound(injector, ErrServiceNotFound, invokerChain)
 	}
 
+	trace := startInvocationTrace(injector, serviceScope, name, invokerChain, service)
 	injector.RootScope().opts.onBeforeInvocation(serviceScope, name)
 	instance, err := service.getInstanceAny(&virtualScope{invokerChain: invokerChain, self: serviceScope})
 	injector.RootScope().opts.onAfterInvocation(serviceScope, name, err)
+	trace(err)
 	if err != nil {
 		return nil, err
 	}
@@ -85,9 +87,11 @@ func invokeByName[T any](i Injector, name string) (T, error) {
 		return empty[T](), serviceTypeMismatch(inferServiceName[T](), serviceAny.(ServiceAny).getTypeName())
 	}
 
+	trace := startInvocationTrace(injector, serviceScope, name, invokerChain, service)
 	injector.RootScope().opts.onBeforeInvocation(serviceScope, name)
 	instance, err := service.getInstance(&virtualScope{invokerChain: invokerChain, self: serviceScope})
 	injector.RootScope().opts.onAfterInvocation(serviceScope, name, err)
+	trace(err)
 
 	if err != nil {
 		return empty[T](), err
@@ -144,14 +148,18 @@ func invokeByGenericType[T any](i Injector) (T, error) {
 		}
 	}
 
+	invokerChain = append(invokerChain, serviceRealName)
+
+	trace := startInvocationTrace(injector, serviceScope, serviceRealName, invokerChain, serviceInstance)
 	injector.RootScope().opts.onBeforeInvocation(serviceScope, serviceAliasName)
 	instance, err := serviceInstance.(serviceGetInstanceAny).getInstanceAny(
 		&virtualScope{
-			invokerChain: append(invokerChain, serviceRealName),
+			invokerChain: invokerChain,
 			self:         serviceScope,
 		},
 	)
 	injector.RootScope().opts.onAfterInvocation(serviceScope, serviceAliasName, err)
+	trace(err)
 
 	if err != nil {
 		return empty[T](), err
@@ -13,6 +13,7 @@ type InjectorOpts struct {
 	HookAfterInvocation    []func(scope *Scope, serviceName string, err error)
 	HookBeforeShutdown     []func(scope *Scope, serviceName string)
 	HookAfterShutdown      []func(scope *Scope, serviceName string, err error)
+	HookInvocation         []func(event InvocationEvent)
 
 	Logf func(format string, args ...any)
 
@@ -20,6 +21,8 @@ type InjectorOpts struct {
 	HealthCheckGlobalTimeout time.Duration // default: no timeout
 	HealthCheckTimeout       time.Duration // default: no timeout
 
+	WarmupParallelism uint // default: all services are built in parallel
+
 	StructTagKey string
 }
 
@@ -47,6 +50,12 @@ func (o *InjectorOpts) onAfterInvocation(scope *Scope, serviceName string, err e
 	}
 }
 
+func (o *InjectorOpts) onInvocation(event InvocationEvent) {
+	for _, fn := range o.HookInvocation {
+		fn(event)
+	}
+}
+
 func (o *InjectorOpts) onBeforeShutdown(scope *Scope, serviceName string) {
 	for _, fn := range o.HookBeforeShutdown {
 		fn(scope, serviceName)
@@ -35,6 +35,9 @@ func NewWithOpts(opts *InjectorOpts, packages ...func(Injector)) *RootScope {
 	if opts.HookAfterInvocation == nil {
 		opts.HookAfterInvocation = []func(*Scope, string, error){}
 	}
+	if opts.HookInvocation == nil {
+		opts.HookInvocation = []func(InvocationEvent){}
+	}
 	if opts.HookBeforeShutdown == nil {
 		opts.HookBeforeShutdown = []func(*Scope, string){}
 	}
@@ -171,6 +174,11 @@ func (s *RootScope) AddAfterInvocationHook(hook func(*Scope, string, error)) {
 	s.opts.HookAfterInvocation = append(s.opts.HookAfterInvocation, hook)
 }
 
+// AddInvocationHook adds a hook that will be called with the timing of every service invocation.
+func (s *RootScope) AddInvocationHook(hook func(InvocationEvent)) {
+	s.opts.HookInvocation = append(s.opts.HookInvocation, hook)
+}
+
 // AddBeforeShutdownHook adds a hook that will be called before a service is shutdown.
 func (s *RootScope) AddBeforeShutdownHook(hook func(*Scope, string)) {
 	s.opts.HookBeforeShutdown = append(s.opts.HookBeforeShutdown, hook)
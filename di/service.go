@@ -71,9 +71,15 @@ type serviceClone interface{ clone() any }
 type serviceSource interface {
 	source() (stacktrace.Frame, []stacktrace.Frame)
 }
+type serviceReload interface {
+	reload(Injector) (reloadedInstance, error)
+}
 type serviceBuildTime interface {
 	getBuildTime() (time.Duration, bool)
 }
+type serviceGetTarget interface {
+	getTarget() (any, bool)
+}
 
 var _ serviceGetName = (Service[int])(nil)
 var _ serviceGetTypeName = (Service[int])(nil)
@@ -127,6 +133,23 @@ func inferServiceInfo(injector Injector, name string) (serviceInfo, bool) {
 	return serviceInfo{}, false
 }
 
+// shutdownInstance calls the Shutdown method of the instance, if any.
+func shutdownInstance(ctx context.Context, instance any) error {
+	if svc, ok := instance.(ShutdownerWithContextAndError); ok {
+		return svc.Shutdown(ctx)
+	} else if svc, ok := instance.(ShutdownerWithError); ok {
+		return svc.Shutdown()
+	} else if svc, ok := instance.(ShutdownerWithContext); ok {
+		svc.Shutdown(ctx)
+		return nil
+	} else if svc, ok := instance.(Shutdowner); ok {
+		svc.Shutdown()
+		return nil
+	}
+
+	return nil
+}
+
 func serviceIsAssignable[T any](service any) bool {
 	if svc, ok := service.(serviceGetEmptyInstance); ok {
 		// we need an empty instance here, because we don't want to instantiate the service when not needed
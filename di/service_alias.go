@@ -13,6 +13,7 @@ var _ Service[int] = (*serviceAlias[int, int])(nil)
 var _ serviceHealthcheck = (*serviceAlias[int, int])(nil)
 var _ serviceShutdown = (*serviceAlias[int, int])(nil)
 var _ serviceClone = (*serviceAlias[int, int])(nil)
+var _ serviceGetTarget = (*serviceAlias[int, int])(nil)
 
 type serviceAlias[Initial any, Alias any] struct {
 	mu         sync.RWMutex
@@ -90,6 +91,12 @@ func (s *serviceAlias[Initial, Alias]) getInstance(i Injector) (Alias, error) {
 	}
 }
 
+// getTarget returns the aliased service.
+func (s *serviceAlias[Initial, Alias]) getTarget() (any, bool) {
+	serviceAny, _, ok := s.scope.serviceGetRec(s.targetName)
+	return serviceAny, ok
+}
+
 func (s *serviceAlias[Initial, Alias]) isHealthchecker() bool {
 	serviceAny, _, ok := s.scope.serviceGetRec(s.targetName)
 	if !ok {
@@ -0,0 +1,5 @@
+package di
+
+type eagerTest struct {
+	foobar string
+}
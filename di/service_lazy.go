@@ -14,9 +14,11 @@ var _ Service[int] = (*serviceLazy[int])(nil)
 var _ serviceHealthcheck = (*serviceLazy[int])(nil)
 var _ serviceShutdown = (*serviceLazy[int])(nil)
 var _ serviceClone = (*serviceLazy[int])(nil)
+var _ serviceReload = (*serviceLazy[int])(nil)
 
 type serviceLazy[T any] struct {
 	mu       sync.RWMutex
+	reloadMu sync.Mutex
 	name     string
 	typeName string
 	instance T
@@ -167,19 +169,49 @@ func (s *serviceLazy[T]) shutdown(ctx context.Context) error {
 		return nil
 	}
 
-	if instance, ok := any(s.instance).(ShutdownerWithContextAndError); ok {
-		return instance.Shutdown(ctx)
-	} else if instance, ok := any(s.instance).(ShutdownerWithError); ok {
-		return instance.Shutdown()
-	} else if instance, ok := any(s.instance).(ShutdownerWithContext); ok {
-		instance.Shutdown(ctx)
-		return nil
-	} else if instance, ok := any(s.instance).(Shutdowner); ok {
-		instance.Shutdown()
-		return nil
+	return shutdownInstance(ctx, s.instance)
+}
+
+// reload builds a new instance and swaps it with the current one.
+// The provider runs without holding the service lock, so that invocations
+// keep being served by the previous instance in the meantime. When the provider
+// fails, the previous instance is kept. Otherwise, the previous instance is shut
+// down on commit, or swapped back on rollback.
+func (s *serviceLazy[T]) reload(i Injector) (reloadedInstance, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	start := time.Now()
+
+	instance, err := handleProviderPanic(s.provider, i)
+	if err != nil {
+		return reloadedInstance{}, err
 	}
 
-	return nil
+	s.mu.Lock()
+	previous, wasBuilt, previousBuildTime := s.instance, s.built, s.buildTime
+	s.instance = instance
+	s.built = true
+	s.buildTime = time.Since(start)
+	s.mu.Unlock()
+
+	return reloadedInstance{
+		commit: func(ctx context.Context) error {
+			if !wasBuilt {
+				return nil
+			}
+			return shutdownInstance(ctx, previous)
+		},
+		rollback: func(ctx context.Context) error {
+			s.mu.Lock()
+			s.instance = previous
+			s.built = wasBuilt
+			s.buildTime = previousBuildTime
+			s.mu.Unlock()
+
+			return shutdownInstance(ctx, instance)
+		},
+	}, nil
 }
 
 func (s *serviceLazy[T]) clone() any {
@@ -212,5 +244,8 @@ func (s *serviceLazy[T]) source() (stacktrace.Frame, []stacktrace.Frame) {
 }
 
 func (s *serviceLazy[T]) getBuildTime() (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.buildTime, s.built
 }
@@ -0,0 +1,430 @@
+// Package eventbus implements an in-process publish/subscribe event bus with typed topics.
+//
+// Topics are dot-separated, like "user.created". A subscription pattern may use wildcards:
+// "*" matches exactly one segment and "#", only allowed as the last segment, matches zero or
+// more segments. A subscriber created with Subscribe[T] only receives the events assignable to T.
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/pool"
+)
+
+// DefaultBufferSize is the buffer size of an async subscriber created without WithBuffer.
+const DefaultBufferSize = 64
+
+var (
+	// ErrClosed is returned when the bus is closed.
+	ErrClosed = errors.New("eventbus: bus is closed")
+
+	// ErrInvalidTopic is returned for an empty topic, a published topic with wildcards,
+	// or a pattern with "#" before its last segment.
+	ErrInvalidTopic = errors.New("eventbus: invalid topic")
+)
+
+// Event is an event delivered to a subscriber.
+type Event[T any] struct {
+	Topic   string
+	Payload T
+}
+
+// Handler handles the events of a subscription.
+type Handler[T any] func(ctx context.Context, event Event[T]) error
+
+// OverflowPolicy tells what an async subscriber does when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// Block blocks the publisher until there is room in the buffer, or its context is done.
+	Block OverflowPolicy = iota
+
+	// DropNewest drops the published event.
+	DropNewest
+
+	// DropOldest drops the oldest buffered event to make room for the published one.
+	DropOldest
+)
+
+// Option configures a bus.
+type Option func(*Bus)
+
+// WithPool runs the async deliveries on the workers of p instead of new goroutines.
+func WithPool(p *pool.Pool) Option {
+	return func(b *Bus) {
+		b.pool = p
+	}
+}
+
+// WithErrorHandler sets the function receiving the errors and panics of the async handlers.
+func WithErrorHandler(fn func(topic string, err error)) Option {
+	return func(b *Bus) {
+		b.onError = fn
+	}
+}
+
+// SubscribeOption configures a subscription.
+type SubscribeOption func(*subscriber)
+
+// Async delivers the events in the background, in publication order, instead of in Publish.
+func Async() SubscribeOption {
+	return func(s *subscriber) {
+		s.async = true
+	}
+}
+
+// WithBuffer makes the subscription async, with a buffer of size events and the given overflow policy.
+func WithBuffer(size int, policy OverflowPolicy) SubscribeOption {
+	return func(s *subscriber) {
+		s.async = true
+		s.size = size
+		s.policy = policy
+	}
+}
+
+// Bus dispatches the published events to the subscribers of their topic.
+type Bus struct {
+	pool    *pool.Pool
+	onError func(topic string, err error)
+
+	// ctx is given to the async handlers, it is canceled when Close gives up draining.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	subsMu sync.RWMutex
+	subs   []*subscriber
+
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	drained  chan struct{}
+}
+
+// New creates an event bus.
+func New(opts ...Option) *Bus {
+	b := &Bus{
+		onError: func(string, error) {},
+		drained: make(chan struct{}),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Subscription is returned by Subscribe.
+type Subscription struct {
+	bus *Bus
+	sub *subscriber
+}
+
+// Topic returns the topic pattern of the subscription.
+func (s *Subscription) Topic() string {
+	return s.sub.topic
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.sub.dropped)
+}
+
+// Unsubscribe stops the delivery of new events, the events already buffered are still delivered.
+func (s *Subscription) Unsubscribe() {
+	s.bus.subsMu.Lock()
+	defer s.bus.subsMu.Unlock()
+
+	for i, sub := range s.bus.subs {
+		if sub == s.sub {
+			s.bus.subs = append(s.bus.subs[:i:i], s.bus.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Subscribe subscribes handler to the events of the topic pattern which are assignable to T.
+// The subscription is sync unless Async or WithBuffer is given.
+func Subscribe[T any](b *Bus, topic string, handler Handler[T], opts ...SubscribeOption) (*Subscription, error) {
+	pattern, ok := parseTopic(topic, true)
+	if !ok {
+		return nil, ErrInvalidTopic
+	}
+
+	s := &subscriber{
+		bus:     b,
+		topic:   topic,
+		pattern: pattern,
+		size:    DefaultBufferSize,
+		accepts: func(event any) bool {
+			_, ok := event.(T)
+			return ok
+		},
+		handle: func(ctx context.Context, topic string, event any) error {
+			return handler(ctx, Event[T]{Topic: topic, Payload: event.(T)})
+		},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.async {
+		if s.size < 1 {
+			s.size = 1
+		}
+		s.queue = make(chan envelope, s.size)
+	}
+
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+
+	b.subsMu.Lock()
+	b.subs = append(b.subs, s)
+	b.subsMu.Unlock()
+
+	return &Subscription{bus: b, sub: s}, nil
+}
+
+// Publish publishes an event on the topic. The sync handlers run before Publish returns, and
+// their errors are joined in the returned error. The events of the async subscribers are buffered.
+func (b *Bus) Publish(ctx context.Context, topic string, event any) error {
+	segments, ok := parseTopic(topic, false)
+	if !ok {
+		return ErrInvalidTopic
+	}
+	if !b.acquire() {
+		return ErrClosed
+	}
+	defer b.release()
+
+	b.subsMu.RLock()
+	subs := make([]*subscriber, 0, len(b.subs))
+	for _, s := range b.subs {
+		if match(s.pattern, segments) && s.accepts(event) {
+			subs = append(subs, s)
+		}
+	}
+	b.subsMu.RUnlock()
+
+	var errs []error
+	for _, s := range subs {
+		if s.async {
+			if err := s.enqueue(ctx, envelope{topic: topic, event: event}); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := s.call(ctx, topic, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return internal.JoinError(errs...)
+}
+
+// Publish publishes a typed event on the topic, see Bus.Publish.
+func Publish[T any](ctx context.Context, b *Bus, topic string, event T) error {
+	return b.Publish(ctx, topic, event)
+}
+
+// Close stops accepting events and waits until the pending events are delivered. When ctx is
+// done first, the context of the async handlers is canceled, the remaining events are dropped,
+// and ctx.Err() is returned.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	b.closed = true
+	if b.inflight == 0 {
+		close(b.drained)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.drained:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// acquire counts a publication in flight, unless the bus is closed.
+func (b *Bus) acquire() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return false
+	}
+	b.inflight++
+	return true
+}
+
+// add counts an event buffered by a publication in flight.
+func (b *Bus) add() {
+	b.mu.Lock()
+	b.inflight++
+	b.mu.Unlock()
+}
+
+func (b *Bus) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inflight--
+	if b.closed && b.inflight == 0 {
+		close(b.drained)
+	}
+}
+
+// schedule runs the delivery of an async subscriber.
+func (b *Bus) schedule(task func()) {
+	if b.pool != nil && b.pool.Submit(task) == nil {
+		return
+	}
+	go task()
+}
+
+type envelope struct {
+	topic string
+	event any
+}
+
+type subscriber struct {
+	bus     *Bus
+	topic   string
+	pattern []string
+	accepts func(event any) bool
+	handle  func(ctx context.Context, topic string, event any) error
+
+	async  bool
+	size   int
+	policy OverflowPolicy
+	queue  chan envelope
+
+	running int32
+	dropped uint64
+}
+
+// call runs the handler, a panic is returned as an error.
+func (s *subscriber) call(ctx context.Context, topic string, event any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("eventbus: panic in handler of %q: %v", s.topic, r)
+		}
+	}()
+	return s.handle(ctx, topic, event)
+}
+
+// enqueue buffers an event according to the overflow policy, and starts the delivery if needed.
+func (s *subscriber) enqueue(ctx context.Context, e envelope) error {
+	s.bus.add()
+
+	switch s.policy {
+	case DropNewest:
+		select {
+		case s.queue <- e:
+		default:
+			s.drop()
+			return nil
+		}
+	case DropOldest:
+		for sent := false; !sent; {
+			select {
+			case s.queue <- e:
+				sent = true
+			default:
+				select {
+				case <-s.queue:
+					s.drop()
+				default:
+				}
+			}
+		}
+	default:
+		select {
+		case s.queue <- e:
+		case <-ctx.Done():
+			s.bus.release()
+			return ctx.Err()
+		case <-s.bus.ctx.Done():
+			s.bus.release()
+			return ErrClosed
+		}
+	}
+
+	if atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		s.bus.schedule(s.drain)
+	}
+	return nil
+}
+
+func (s *subscriber) drop() {
+	atomic.AddUint64(&s.dropped, 1)
+	s.bus.release()
+}
+
+// drain delivers the buffered events until the buffer is empty.
+func (s *subscriber) drain() {
+	for {
+		select {
+		case e := <-s.queue:
+			if s.bus.ctx.Err() == nil {
+				if err := s.call(s.bus.ctx, e.topic, e.event); err != nil {
+					s.bus.onError(e.topic, err)
+				}
+			}
+			s.bus.release()
+		default:
+			atomic.StoreInt32(&s.running, 0)
+			// an event may have been buffered after the buffer was found empty
+			if len(s.queue) == 0 || !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+				return
+			}
+		}
+	}
+}
+
+// parseTopic splits a topic into segments, wildcards are only valid in patterns.
+func parseTopic(topic string, pattern bool) ([]string, bool) {
+	if topic == "" {
+		return nil, false
+	}
+
+	segments := strings.Split(topic, ".")
+	for i, seg := range segments {
+		switch {
+		case seg == "":
+			return nil, false
+		case seg == "*" || seg == "#":
+			if !pattern || (seg == "#" && i != len(segments)-1) {
+				return nil, false
+			}
+		}
+	}
+	return segments, true
+}
+
+// match reports whether the topic segments match the pattern segments.
+func match(pattern, topic []string) bool {
+	for i, p := range pattern {
+		if p == "#" {
+			return true
+		}
+		if i >= len(topic) || (p != "*" && p != topic[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(topic)
+}
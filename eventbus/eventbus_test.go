@@ -0,0 +1,221 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/pool"
+)
+
+type userCreated struct {
+	Name string
+}
+
+func TestSubscribeSync(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestSubscribeSync")
+
+	bus := New()
+	var got []string
+	sub, err := Subscribe(bus, "user.created", func(ctx context.Context, e Event[userCreated]) error {
+		got = append(got, e.Topic+":"+e.Payload.Name)
+		return nil
+	})
+	assert.IsNil(err)
+	assert.Equal("user.created", sub.Topic())
+
+	_, err = Subscribe(bus, "user.created", func(ctx context.Context, e Event[string]) error {
+		return errors.New("error")
+	})
+	assert.IsNil(err)
+
+	assert.IsNil(Publish(context.Background(), bus, "user.created", userCreated{Name: "alice"}))
+	// the string subscriber only receives strings
+	assert.Equal("error", bus.Publish(context.Background(), "user.created", "bob").Error())
+	assert.IsNil(bus.Publish(context.Background(), "user.deleted", userCreated{Name: "carol"}))
+	assert.Equal([]string{"user.created:alice"}, got)
+
+	sub.Unsubscribe()
+	assert.IsNil(Publish(context.Background(), bus, "user.created", userCreated{Name: "dave"}))
+	assert.Equal(1, len(got))
+
+	_, err = Subscribe(bus, "user.panic", func(ctx context.Context, e Event[int]) error {
+		panic("boom")
+	})
+	assert.IsNil(err)
+	assert.IsNotNil(bus.Publish(context.Background(), "user.panic", 1))
+}
+
+func TestWildcards(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestWildcards")
+
+	tests := []struct {
+		pattern string
+		topic   string
+		match   bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.*", "a.b", true},
+		{"a.*", "a.b.c", false},
+		{"*.b", "a.b", true},
+		{"a.#", "a", true},
+		{"a.#", "a.b.c", true},
+		{"#", "a.b", true},
+		{"a.*.c", "a.b.c", true},
+		{"a.*.c", "a.b.d", false},
+		{"a.b", "a", false},
+	}
+	for _, tt := range tests {
+		pattern, ok := parseTopic(tt.pattern, true)
+		assert.ShouldBeTrue(ok)
+		topic, ok := parseTopic(tt.topic, false)
+		assert.ShouldBeTrue(ok)
+		assert.Equal(tt.match, match(pattern, topic))
+	}
+
+	bus := New()
+	for _, invalid := range []string{"", "a..b", "#.a", "a.#.b"} {
+		_, err := Subscribe(bus, invalid, func(ctx context.Context, e Event[any]) error { return nil })
+		assert.Equal(ErrInvalidTopic, err)
+	}
+	assert.Equal(ErrInvalidTopic, bus.Publish(context.Background(), "a.*", 1))
+
+	var topics []string
+	_, err := Subscribe(bus, "order.#", func(ctx context.Context, e Event[any]) error {
+		topics = append(topics, e.Topic)
+		return nil
+	})
+	assert.IsNil(err)
+	assert.IsNil(bus.Publish(context.Background(), "order.created", 1))
+	assert.IsNil(bus.Publish(context.Background(), "order.item.added", "x"))
+	assert.Equal([]string{"order.created", "order.item.added"}, topics)
+}
+
+func TestAsync(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestAsync")
+
+	p, err := pool.NewPool(4)
+	assert.IsNil(err)
+	defer p.Release()
+
+	var mu sync.Mutex
+	var errs []error
+	bus := New(WithPool(p), WithErrorHandler(func(topic string, err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}))
+
+	var got []int
+	_, err = Subscribe(bus, "numbers", func(ctx context.Context, e Event[int]) error {
+		time.Sleep(time.Millisecond)
+		got = append(got, e.Payload)
+		if e.Payload == 5 {
+			return errors.New("error")
+		}
+		return nil
+	}, Async())
+	assert.IsNil(err)
+
+	for i := 0; i < 10; i++ {
+		assert.IsNil(Publish(context.Background(), bus, "numbers", i))
+	}
+
+	// Close drains the pending events
+	assert.IsNil(bus.Close(context.Background()))
+	assert.Equal([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, got)
+	assert.Equal(1, len(errs))
+
+	assert.Equal(ErrClosed, bus.Publish(context.Background(), "numbers", 10))
+	assert.Equal(ErrClosed, bus.Close(context.Background()))
+	_, err = Subscribe(bus, "numbers", func(ctx context.Context, e Event[int]) error { return nil })
+	assert.Equal(ErrClosed, err)
+}
+
+func TestOverflow(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestOverflow")
+
+	for _, tt := range []struct {
+		policy OverflowPolicy
+		want   []int
+	}{
+		{DropNewest, []int{0, 1, 2}},
+		{DropOldest, []int{0, 3, 4}},
+	} {
+		bus := New()
+		release := make(chan struct{})
+		started := make(chan struct{}, 1)
+		var got []int
+		sub, err := Subscribe(bus, "numbers", func(ctx context.Context, e Event[int]) error {
+			if e.Payload == 0 {
+				started <- struct{}{}
+				<-release
+			}
+			got = append(got, e.Payload)
+			return nil
+		}, WithBuffer(2, tt.policy))
+		assert.IsNil(err)
+
+		assert.IsNil(bus.Publish(context.Background(), "numbers", 0))
+		<-started
+		for i := 1; i < 5; i++ {
+			assert.IsNil(bus.Publish(context.Background(), "numbers", i))
+		}
+		assert.Equal(uint64(2), sub.Dropped())
+
+		close(release)
+		assert.IsNil(bus.Close(context.Background()))
+		assert.Equal(tt.want, got)
+	}
+
+	// a blocked publisher gives up with its context
+	bus := New()
+	release := make(chan struct{})
+	_, err := Subscribe(bus, "numbers", func(ctx context.Context, e Event[int]) error {
+		<-release
+		return nil
+	}, WithBuffer(1, Block))
+	assert.IsNil(err)
+
+	assert.IsNil(bus.Publish(context.Background(), "numbers", 0))
+	// the first event may still be buffered
+	_ = bus.Publish(context.Background(), "numbers", 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ShouldBeTrue(errors.Is(bus.Publish(ctx, "numbers", 2), context.DeadlineExceeded))
+
+	close(release)
+	assert.IsNil(bus.Close(context.Background()))
+}
+
+func TestCloseTimeout(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestCloseTimeout")
+
+	bus := New()
+	canceled := make(chan struct{})
+	_, err := Subscribe(bus, "slow", func(ctx context.Context, e Event[int]) error {
+		<-ctx.Done()
+		close(canceled)
+		return nil
+	}, Async())
+	assert.IsNil(err)
+	assert.IsNil(bus.Publish(context.Background(), "slow", 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(context.DeadlineExceeded, bus.Close(ctx))
+	<-canceled
+}
@@ -218,3 +218,8 @@ func ToChannel[T any](ctx context.Context, iter Iterator[T], buffer int) <-chan
 
 	return result
 }
+
+// FromFunc creates an iterator over the items returned by next, until it returns false.
+func FromFunc[T any](next func() (item T, ok bool)) Iterator[T] {
+	return newLookahead(next)
+}
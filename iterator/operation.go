@@ -7,6 +7,8 @@
 // Hope that Go can support iterator in future. see https://github.com/golang/go/discussions/54245 and https://github.com/golang/go/discussions/56413
 package iterator
 
+import "github.com/sllt/af/tuple"
+
 // Map creates a new iterator which applies a function to all items of input iterator.
 func Map[T any, U any](iter Iterator[T], iteratee func(item T) U) Iterator[U] {
 	return &mapIterator[T, U]{
@@ -134,3 +136,138 @@ func (iter *takeIterator[T]) Next() (T, bool) {
 func (iter *takeIterator[T]) HasNext() bool {
 	return iter.num > 0
 }
+
+// Zip creates an iterator of the pairs of items of a and b at the same position,
+// it ends with the shortest iterator.
+func Zip[A any, B any](a Iterator[A], b Iterator[B]) Iterator[tuple.Tuple2[A, B]] {
+	return newLookahead(func() (tuple.Tuple2[A, B], bool) {
+		itemA, ok := a.Next()
+		if !ok {
+			return tuple.Tuple2[A, B]{}, false
+		}
+		itemB, ok := b.Next()
+		if !ok {
+			return tuple.Tuple2[A, B]{}, false
+		}
+		return tuple.NewTuple2(itemA, itemB), true
+	})
+}
+
+// Chunk creates an iterator of the items of iter split into slices of the given size, the last one may be smaller.
+func Chunk[T any](iter Iterator[T], size int) Iterator[[]T] {
+	if size <= 0 {
+		panic("Chunk: size should be positive")
+	}
+
+	return newLookahead(func() ([]T, bool) {
+		chunk := make([]T, 0, size)
+		for len(chunk) < size {
+			item, ok := iter.Next()
+			if !ok {
+				break
+			}
+			chunk = append(chunk, item)
+		}
+		return chunk, len(chunk) > 0
+	})
+}
+
+// Flatten creates an iterator of the items of all the iterators returned by iters.
+func Flatten[T any](iters Iterator[Iterator[T]]) Iterator[T] {
+	var current Iterator[T]
+
+	return newLookahead(func() (T, bool) {
+		for {
+			if current != nil {
+				if item, ok := current.Next(); ok {
+					return item, true
+				}
+			}
+			next, ok := iters.Next()
+			if !ok {
+				var zero T
+				return zero, false
+			}
+			current = next
+		}
+	})
+}
+
+// Distinct creates an iterator which skips the items already returned.
+func Distinct[T comparable](iter Iterator[T]) Iterator[T] {
+	seen := make(map[T]struct{})
+
+	return newLookahead(func() (T, bool) {
+		for item, ok := iter.Next(); ok; item, ok = iter.Next() {
+			if _, found := seen[item]; !found {
+				seen[item] = struct{}{}
+				return item, true
+			}
+		}
+		var zero T
+		return zero, false
+	})
+}
+
+// Skip creates an iterator which skips the first num items of iter.
+func Skip[T any](iter Iterator[T], num int) Iterator[T] {
+	return newLookahead(func() (T, bool) {
+		for ; num > 0; num-- {
+			if _, ok := iter.Next(); !ok {
+				num = 0
+				var zero T
+				return zero, false
+			}
+		}
+		return iter.Next()
+	})
+}
+
+// TakeWhile creates an iterator which returns the items of iter until predicate returns false.
+func TakeWhile[T any](iter Iterator[T], predicate func(item T) bool) Iterator[T] {
+	done := false
+
+	return newLookahead(func() (T, bool) {
+		var zero T
+		if done {
+			return zero, false
+		}
+		item, ok := iter.Next()
+		if !ok || !predicate(item) {
+			done = true
+			return zero, false
+		}
+		return item, true
+	})
+}
+
+// lookahead is an iterator over the items returned by a function, HasNext reads one item ahead.
+type lookahead[T any] struct {
+	next   func() (T, bool)
+	item   T
+	ok     bool
+	peeked bool
+}
+
+func newLookahead[T any](next func() (T, bool)) *lookahead[T] {
+	return &lookahead[T]{next: next}
+}
+
+func (iter *lookahead[T]) HasNext() bool {
+	if !iter.peeked {
+		iter.item, iter.ok = iter.next()
+		iter.peeked = true
+	}
+	return iter.ok
+}
+
+func (iter *lookahead[T]) Next() (T, bool) {
+	if iter.peeked {
+		iter.peeked = false
+		item := iter.item
+		var zero T
+		iter.item = zero
+		return item, iter.ok
+	}
+	return iter.next()
+}
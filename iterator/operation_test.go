@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/sllt/af/internal"
+	"github.com/sllt/af/tuple"
 )
 
 func TestMapIterator(t *testing.T) {
@@ -78,3 +79,76 @@ func TestTakeIterator(t *testing.T) {
 	result := ToSlice(iter)
 	assert.Equal([]int{1, 2, 3}, result)
 }
+
+func TestZipIterator(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestZipIterator")
+
+	iter := Zip[int, string](FromSlice([]int{1, 2, 3}), FromSlice([]string{"a", "b"}))
+
+	assert.Equal(true, iter.HasNext())
+	assert.Equal([]tuple.Tuple2[int, string]{
+		tuple.NewTuple2(1, "a"),
+		tuple.NewTuple2(2, "b"),
+	}, ToSlice(iter))
+	assert.Equal(false, iter.HasNext())
+}
+
+func TestChunkIterator(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestChunkIterator")
+
+	iter := Chunk[int](FromSlice([]int{1, 2, 3, 4, 5}), 2)
+	assert.Equal([][]int{{1, 2}, {3, 4}, {5}}, ToSlice(iter))
+
+	iter = Chunk[int](FromSlice([]int{}), 2)
+	assert.Equal(false, iter.HasNext())
+}
+
+func TestFlattenIterator(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestFlattenIterator")
+
+	iters := FromSlice([]Iterator[int]{
+		FromSlice([]int{1, 2}),
+		FromSlice([]int{}),
+		FromSlice([]int{3}),
+	})
+	assert.Equal([]int{1, 2, 3}, ToSlice(Flatten[int](iters)))
+}
+
+func TestDistinctIterator(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestDistinctIterator")
+
+	iter := Distinct[int](FromSlice([]int{1, 2, 1, 3, 2}))
+	assert.Equal([]int{1, 2, 3}, ToSlice(iter))
+}
+
+func TestSkipIterator(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestSkipIterator")
+
+	assert.Equal([]int{3, 4}, ToSlice(Skip[int](FromSlice([]int{1, 2, 3, 4}), 2)))
+	assert.Equal([]int{}, ToSlice(Skip[int](FromSlice([]int{1, 2}), 3)))
+
+	iter := Skip[int](FromRange(0, 10, 1), 8)
+	assert.Equal(true, iter.HasNext())
+	assert.Equal([]int{8, 9}, ToSlice(iter))
+}
+
+func TestTakeWhileIterator(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestTakeWhileIterator")
+
+	iter := TakeWhile[int](FromSlice([]int{1, 2, 3, 1}), func(n int) bool { return n < 3 })
+	assert.Equal(true, iter.HasNext())
+	assert.Equal([]int{1, 2}, ToSlice(iter))
+	assert.Equal(false, iter.HasNext())
+}
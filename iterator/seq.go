@@ -0,0 +1,32 @@
+package iterator
+
+// The sequences below have the signatures of iter.Seq and iter.Seq2 of Go 1.23, without naming
+// these types since the module targets an older Go version: they can be ranged over with a Go 1.23
+// compiler, and assigned to iter.Seq or iter.Seq2 variables. The functions turning a sequence into
+// an Iterator need iter.Pull, they are in seq_go123.go.
+
+// ToSeq returns a sequence of the remaining items of iter, like iter.Seq.
+// The sequence consumes iter, so it can only be ranged over once.
+func ToSeq[T any](iter Iterator[T]) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for item, ok := iter.Next(); ok; item, ok = iter.Next() {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// ToSeq2 returns a sequence of the remaining items of iter with their index, like iter.Seq2.
+// The sequence consumes iter, so it can only be ranged over once.
+func ToSeq2[T any](iter Iterator[T]) func(yield func(int, T) bool) {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for item, ok := iter.Next(); ok; item, ok = iter.Next() {
+			if !yield(i, item) {
+				return
+			}
+			i++
+		}
+	}
+}
@@ -0,0 +1,39 @@
+//go:build go1.23
+
+package iterator
+
+import (
+	"iter"
+
+	"github.com/sllt/af/tuple"
+)
+
+// FromSeq returns an iterator over the items of seq. Stop must be called when the iterator
+// is not consumed until its end, to release the sequence.
+func FromSeq[T any](seq iter.Seq[T]) StopIterator[T] {
+	next, stop := iter.Pull(seq)
+	return &seqIterator[T]{lookahead: newLookahead(next), stop: stop}
+}
+
+// FromSeq2 returns an iterator over the pairs of seq. Stop must be called when the iterator
+// is not consumed until its end, to release the sequence.
+func FromSeq2[K any, V any](seq iter.Seq2[K, V]) StopIterator[tuple.Tuple2[K, V]] {
+	next, stop := iter.Pull2(seq)
+	return &seqIterator[tuple.Tuple2[K, V]]{
+		lookahead: newLookahead(func() (tuple.Tuple2[K, V], bool) {
+			k, v, ok := next()
+			return tuple.NewTuple2(k, v), ok
+		}),
+		stop: stop,
+	}
+}
+
+type seqIterator[T any] struct {
+	*lookahead[T]
+	stop func()
+}
+
+// Stop implements StopIterator.
+func (iter *seqIterator[T]) Stop() {
+	iter.stop()
+}
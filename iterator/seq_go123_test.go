@@ -0,0 +1,54 @@
+//go:build go1.23
+
+package iterator
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/tuple"
+)
+
+func TestFromSeq(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestFromSeq")
+
+	iter := FromSeq(slices.Values([]int{1, 2, 3}))
+	assert.Equal(true, iter.HasNext())
+	assert.Equal([]int{1, 2, 3}, ToSlice[int](iter))
+	iter.Stop()
+
+	// stopped before its end
+	iter = FromSeq(slices.Values([]int{1, 2, 3}))
+	item, ok := iter.Next()
+	assert.Equal(1, item)
+	assert.Equal(true, ok)
+	iter.Stop()
+	_, ok = iter.Next()
+	assert.Equal(false, ok)
+
+	pairs := FromSeq2(maps.All(map[string]int{"a": 1}))
+	assert.Equal([]tuple.Tuple2[string, int]{tuple.NewTuple2("a", 1)}, ToSlice[tuple.Tuple2[string, int]](pairs))
+}
+
+func TestRangeOverSeq(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestRangeOverSeq")
+
+	result := []int{}
+	for n := range ToSeq[int](FromRange(0, 10, 1)) {
+		if n == 3 {
+			break
+		}
+		result = append(result, n)
+	}
+	assert.Equal([]int{0, 1, 2}, result)
+
+	for i, s := range ToSeq2[string](FromSlice([]string{"a", "b"})) {
+		assert.Equal([]string{"a", "b"}[i], s)
+	}
+}
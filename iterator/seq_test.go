@@ -0,0 +1,44 @@
+package iterator
+
+import (
+	"testing"
+
+	"github.com/sllt/af/internal"
+)
+
+func TestToSeq(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestToSeq")
+
+	seq := ToSeq[int](FromSlice([]int{1, 2, 3, 4}))
+
+	result := []int{}
+	seq(func(n int) bool {
+		result = append(result, n)
+		return n < 3
+	})
+	assert.Equal([]int{1, 2, 3}, result)
+
+	indexes := []int{}
+	ToSeq2[string](FromSlice([]string{"a", "b"}))(func(i int, s string) bool {
+		indexes = append(indexes, i)
+		return true
+	})
+	assert.Equal([]int{0, 1}, indexes)
+}
+
+func TestFromFunc(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestFromFunc")
+
+	n := 0
+	iter := FromFunc(func() (int, bool) {
+		n++
+		return n, n <= 3
+	})
+	assert.Equal(true, iter.HasNext())
+	assert.Equal(true, iter.HasNext())
+	assert.Equal([]int{1, 2, 3}, ToSlice(iter))
+}
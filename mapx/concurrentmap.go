@@ -89,6 +89,23 @@ func (cm *ConcurrentMap[K, V]) Delete(key K) {
 	cm.locks[shard].Unlock()
 }
 
+// DeleteIf deletes the value for a key if condition returns true for it.
+// It reports whether the value was deleted.
+func (cm *ConcurrentMap[K, V]) DeleteIf(key K, condition func(value V) bool) bool {
+	shard := cm.getShard(key)
+
+	cm.locks[shard].Lock()
+	defer cm.locks[shard].Unlock()
+
+	value, ok := cm.maps[shard][key]
+	if !ok || !condition(value) {
+		return false
+	}
+
+	delete(cm.maps[shard], key)
+	return true
+}
+
 // GetAndDelete returns the existing value for the key if present and then delete the value for the key.
 // Otherwise, do nothing, just return false
 func (cm *ConcurrentMap[K, V]) GetAndDelete(key K) (actual V, ok bool) {
@@ -95,6 +95,32 @@ func TestConcurrentMap_Delete(t *testing.T) {
 	}
 }
 
+func TestConcurrentMap_DeleteIf(t *testing.T) {
+	assert := internal.NewAssert(t, "TestConcurrentMap_DeleteIf")
+
+	cm := NewConcurrentMap[string, int](100)
+
+	var wg sync.WaitGroup
+	wg.Add(10)
+
+	for i := 0; i < 10; i++ {
+		go func(n int) {
+			cm.Set(fmt.Sprintf("%d", n), n)
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	isEven := func(value int) bool { return value%2 == 0 }
+	for i := 0; i < 10; i++ {
+		assert.Equal(i%2 == 0, cm.DeleteIf(fmt.Sprintf("%d", i), isEven))
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(i%2 != 0, cm.Has(fmt.Sprintf("%d", i)))
+	}
+	assert.Equal(false, cm.DeleteIf("not-found", isEven))
+}
+
 func TestConcurrentMap_GetAndDelete(t *testing.T) {
 	assert := internal.NewAssert(t, "TestConcurrentMap_GetAndDelete")
 
@@ -0,0 +1,169 @@
+package netx
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Codec encodes and decodes the bodies of a content type.
+type Codec interface {
+	// ContentType returns the content type of the encoded bodies.
+	ContentType() string
+
+	// Encode writes the encoding of v to w.
+	Encode(w io.Writer, v any) error
+
+	// Decode reads the encoding of a value from r and stores it in the value pointed to by v.
+	Decode(r io.Reader, v any) error
+}
+
+var (
+	// JSONCodec encodes the bodies as JSON.
+	JSONCodec Codec = jsonCodec{}
+
+	// XMLCodec encodes the bodies as XML.
+	XMLCodec Codec = xmlCodec{}
+
+	// FormCodec encodes the bodies as URL-encoded forms. It encodes url.Values, map[string]string
+	// and structs, like StructToUrlValues, and decodes into *url.Values and *map[string]string.
+	FormCodec Codec = formCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		"application/json":                  JSONCodec,
+		"application/xml":                   XMLCodec,
+		"text/xml":                          XMLCodec,
+		"application/x-www-form-urlencoded": FormCodec,
+	}
+)
+
+// RegisterCodec registers the codec of the given media types, like "application/msgpack",
+// no media type means the media type of codec.ContentType(). It replaces the codec previously
+// registered for a media type.
+func RegisterCodec(codec Codec, mediaTypes ...string) {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{codec.ContentType()}
+	}
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	for _, mediaType := range mediaTypes {
+		if mt, _, err := mime.ParseMediaType(mediaType); err == nil {
+			mediaType = mt
+		}
+		codecs[strings.ToLower(mediaType)] = codec
+	}
+}
+
+// CodecFor returns the codec registered for the media type of a Content-Type header value.
+// The media types with a "+json" or "+xml" suffix, like "application/problem+json", fall back
+// to JSONCodec and XMLCodec.
+func CodecFor(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	codecsMu.RLock()
+	codec, ok := codecs[mediaType]
+	codecsMu.RUnlock()
+	if ok {
+		return codec, true
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return JSONCodec, true
+	case strings.HasSuffix(mediaType, "+xml"):
+		return XMLCodec, true
+	}
+	return nil, false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return "application/xml"
+}
+
+func (xmlCodec) Encode(w io.Writer, v any) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (xmlCodec) Decode(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+type formCodec struct{}
+
+func (formCodec) ContentType() string {
+	return "application/x-www-form-urlencoded"
+}
+
+func (formCodec) Encode(w io.Writer, v any) error {
+	var values url.Values
+	switch form := v.(type) {
+	case url.Values:
+		values = form
+	case map[string]string:
+		values = url.Values{}
+		for k := range form {
+			values.Set(k, form[k])
+		}
+	default:
+		var err error
+		if values, err = StructToUrlValues(v); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, values.Encode())
+	return err
+}
+
+func (formCodec) Decode(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch form := v.(type) {
+	case *url.Values:
+		*form = values
+	case *map[string]string:
+		*form = make(map[string]string, len(values))
+		for k := range values {
+			(*form)[k] = values.Get(k)
+		}
+	default:
+		return fmt.Errorf("netx: form codec can't decode into %T", v)
+	}
+	return nil
+}
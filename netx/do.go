@@ -0,0 +1,210 @@
+package netx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// ResponseError is returned by Do for a response with a non-2xx status code,
+// E is the type of its decoded body.
+type ResponseError[E any] struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+
+	// Body is the decoded body, it is the zero value when the body can't be decoded.
+	Body E
+
+	// Raw is the body as received.
+	Raw []byte
+}
+
+// Error returns the status of the response.
+func (e *ResponseError[E]) Error() string {
+	return "netx: unexpected response status: " + e.Status
+}
+
+// RequestOption configures a request sent by Do.
+type RequestOption func(*requestConfig)
+
+type requestConfig struct {
+	header http.Header
+	query  url.Values
+	codec  Codec
+}
+
+// WithRequestHeader adds a header to the request.
+func WithRequestHeader(key, value string) RequestOption {
+	return func(c *requestConfig) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithRequestQuery adds query string params to the request url.
+func WithRequestQuery(query url.Values) RequestOption {
+	return func(c *requestConfig) {
+		for key, vals := range query {
+			for _, val := range vals {
+				c.query.Add(key, val)
+			}
+		}
+	}
+}
+
+// WithCodec sets the codec encoding the request body, and decoding the responses without a
+// registered codec for their Content-Type. The default codec is JSONCodec.
+func WithCodec(codec Codec) RequestOption {
+	return func(c *requestConfig) {
+		c.codec = codec
+	}
+}
+
+// Do sends a request with body encoded by the codec, and decodes the response body into a Resp
+// with the codec of the response Content-Type, see CodecFor. A nil body, including a nil
+// pointer, map or slice, sends no body, a *MultipartBody or an io.Reader body is sent as is. An empty response body gives the zero Resp.
+//
+// A response with a non-2xx status code returns a *ResponseError[E], holding the body decoded
+// into an E. A nil client means http.DefaultClient.
+func Do[Req, Resp, E any](ctx context.Context, client *HttpClient, method, rawUrl string, body Req, opts ...RequestOption) (Resp, error) {
+	var result Resp
+
+	config := &requestConfig{header: make(http.Header), query: url.Values{}, codec: JSONCodec}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if len(config.query) > 0 {
+		if strings.Contains(rawUrl, "?") {
+			rawUrl += "&" + config.query.Encode()
+		} else {
+			rawUrl += "?" + config.query.Encode()
+		}
+	}
+
+	req, err := newEncodedRequest(ctx, method, rawUrl, body, config.codec)
+	if err != nil {
+		return result, err
+	}
+	for key, vals := range config.header {
+		req.Header[key] = vals
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", config.codec.ContentType())
+	}
+
+	httpClient := http.DefaultClient
+	if client != nil {
+		client.setTLS(rawUrl)
+		httpClient = client.Client
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+
+	codec, ok := CodecFor(resp.Header.Get("Content-Type"))
+	if !ok {
+		codec = config.codec
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respErr := &ResponseError[E]{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header,
+			Raw:        raw,
+		}
+		if len(raw) > 0 {
+			var decoded E
+			if codec.Decode(bytes.NewReader(raw), &decoded) == nil {
+				respErr.Body = decoded
+			}
+		}
+		return result, respErr
+	}
+
+	if len(raw) == 0 {
+		return result, nil
+	}
+	if err := codec.Decode(bytes.NewReader(raw), &result); err != nil {
+		return result, fmt.Errorf("netx: decode response: %w", err)
+	}
+	return result, nil
+}
+
+// DoJSON sends a request with body encoded as JSON, and decodes the JSON response into a Resp, see Do.
+// A response with a non-2xx status code returns a *ResponseError[any].
+func DoJSON[Req, Resp any](ctx context.Context, client *HttpClient, method, rawUrl string, body Req, opts ...RequestOption) (Resp, error) {
+	opts = append([]RequestOption{WithCodec(JSONCodec)}, opts...)
+	return Do[Req, Resp, any](ctx, client, method, rawUrl, body, opts...)
+}
+
+// newEncodedRequest creates a request with body encoded by codec.
+func newEncodedRequest(ctx context.Context, method, rawUrl string, body any, codec Codec) (*http.Request, error) {
+	if isNilBody(body) {
+		return http.NewRequestWithContext(ctx, method, rawUrl, nil)
+	}
+
+	switch b := body.(type) {
+	case *MultipartBody:
+		length, err := b.Len()
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, method, rawUrl, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = b.Reader()
+		req.GetBody = func() (io.ReadCloser, error) {
+			return b.Reader(), nil
+		}
+		req.ContentLength = length
+		req.Header.Set("Content-Type", b.ContentType())
+		return req, nil
+	case io.Reader:
+		req, err := http.NewRequestWithContext(ctx, method, rawUrl, b)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", codec.ContentType())
+		return req, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := codec.Encode(buf, body); err != nil {
+		return nil, fmt.Errorf("netx: encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawUrl, buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", codec.ContentType())
+	return req, nil
+}
+
+// isNilBody reports whether body is nil, or a nil value of a type which can be nil, which a type
+// switch on nil doesn't catch.
+func isNilBody(body any) bool {
+	if body == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(body); v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
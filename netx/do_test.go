@@ -0,0 +1,229 @@
+package netx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sllt/af/internal"
+)
+
+type todo struct {
+	XMLName xml.Name `json:"-" xml:"todo"`
+	Id      int      `json:"id" xml:"id"`
+	Title   string   `json:"title" xml:"title"`
+}
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestDoJSON(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDoJSON")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/todos":
+			var in todo
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				http.Error(w, "invalid todo", http.StatusBadRequest)
+				return
+			}
+			in.Id = 1
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("X-Accept", r.Header.Get("Accept"))
+			json.NewEncoder(w).Encode(in)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/query":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(r.URL.Query().Get("q") + r.Header.Get("X-Token"))
+		default:
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(apiError{Code: "not_found", Message: "no such todo"})
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	created, err := DoJSON[todo, todo](ctx, nil, http.MethodPost, server.URL+"/todos", todo{Title: "write tests"})
+	assert.IsNil(err)
+	assert.Equal(1, created.Id)
+	assert.Equal("write tests", created.Title)
+
+	_, err = DoJSON[any, struct{}](ctx, nil, http.MethodDelete, server.URL+"/empty", nil)
+	assert.IsNil(err)
+
+	q, err := DoJSON[any, string](ctx, NewHttpClient(), http.MethodGet, server.URL+"/query", nil,
+		WithRequestQuery(url.Values{"q": {"value"}}), WithRequestHeader("X-Token", "-token"))
+	assert.IsNil(err)
+	assert.Equal("value-token", q)
+
+	// typed error body
+	_, err = Do[any, todo, apiError](ctx, nil, http.MethodGet, server.URL+"/todos/2", nil)
+	var respErr *ResponseError[apiError]
+	assert.ShouldBeTrue(errors.As(err, &respErr))
+	assert.Equal(http.StatusNotFound, respErr.StatusCode)
+	assert.Equal(apiError{Code: "not_found", Message: "no such todo"}, respErr.Body)
+	assert.Equal("netx: unexpected response status: 404 Not Found", err.Error())
+
+	// the body of DoJSON errors is decoded into an any
+	_, err = DoJSON[any, todo](ctx, nil, http.MethodGet, server.URL+"/todos/2", nil)
+	var anyErr *ResponseError[any]
+	assert.ShouldBeTrue(errors.As(err, &anyErr))
+	assert.Equal("not_found", anyErr.Body.(map[string]any)["code"])
+
+	// an undecodable error body is kept raw
+	_, err = DoJSON[io.Reader, todo](ctx, nil, http.MethodPost, server.URL+"/todos", strings.NewReader("{"))
+	assert.ShouldBeTrue(errors.As(err, &anyErr))
+	assert.Equal(http.StatusBadRequest, anyErr.StatusCode)
+	assert.IsNil(anyErr.Body)
+	assert.Equal("invalid todo\n", string(anyErr.Raw))
+
+	_, err = DoJSON[func(), todo](ctx, nil, http.MethodPost, server.URL+"/todos", func() {})
+	assert.IsNotNil(err)
+}
+
+func TestDoNilBody(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDoNilBody")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(string(raw))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	body, err := DoJSON[*todo, string](ctx, nil, http.MethodPost, server.URL, nil)
+	assert.IsNil(err)
+	assert.Equal("", body)
+
+	body, err = DoJSON[map[string]int, string](ctx, nil, http.MethodPost, server.URL, nil)
+	assert.IsNil(err)
+	assert.Equal("", body)
+
+	body, err = DoJSON[[]int, string](ctx, nil, http.MethodPost, server.URL, nil)
+	assert.IsNil(err)
+	assert.Equal("", body)
+
+	body, err = DoJSON[*MultipartBody, string](ctx, nil, http.MethodPost, server.URL, nil)
+	assert.IsNil(err)
+	assert.Equal("", body)
+
+	// an empty slice is not nil
+	body, err = DoJSON[[]int, string](ctx, nil, http.MethodPost, server.URL, []int{})
+	assert.IsNil(err)
+	assert.Equal("[]\n", body)
+}
+
+func TestDoCodecs(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDoCodecs")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Content-Type") {
+		case "application/xml":
+			var in todo
+			xml.NewDecoder(r.Body).Decode(&in)
+			in.Id = 2
+			w.Header().Set("Content-Type", "text/xml")
+			xml.NewEncoder(w).Encode(in)
+		case "application/x-www-form-urlencoded":
+			r.ParseForm()
+			w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+			w.Write([]byte(url.Values{"title": {r.PostForm.Get("title") + "!"}}.Encode()))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	got, err := Do[todo, todo, any](ctx, nil, http.MethodPut, server.URL, todo{Title: "xml"}, WithCodec(XMLCodec))
+	assert.IsNil(err)
+	assert.Equal(2, got.Id)
+	assert.Equal("xml", got.Title)
+
+	form, err := Do[map[string]string, url.Values, any](ctx, nil, http.MethodPost, server.URL,
+		map[string]string{"title": "form"}, WithCodec(FormCodec))
+	assert.IsNil(err)
+	assert.Equal("form!", form.Get("title"))
+
+	m, err := Do[todo, map[string]string, any](ctx, nil, http.MethodPost, server.URL, todo{Title: "struct"}, WithCodec(FormCodec))
+	assert.IsNil(err)
+	assert.Equal("struct!", m["title"])
+
+	_, err = Do[todo, todo, any](ctx, nil, http.MethodPost, server.URL, todo{Title: "struct"}, WithCodec(FormCodec))
+	assert.IsNotNil(err)
+}
+
+type upperCodec struct{}
+
+func (upperCodec) ContentType() string {
+	return "text/x-upper"
+}
+
+func (upperCodec) Encode(w io.Writer, v any) error {
+	_, err := io.WriteString(w, strings.ToUpper(v.(string)))
+	return err
+}
+
+func (upperCodec) Decode(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	*v.(*string) = strings.ToLower(string(data))
+	return err
+}
+
+func TestCodecFor(t *testing.T) {
+	assert := internal.NewAssert(t, "TestCodecFor")
+
+	for contentType, want := range map[string]Codec{
+		"application/json":                  JSONCodec,
+		"application/json; charset=utf-8":   JSONCodec,
+		"application/vnd.api+json":          JSONCodec,
+		"text/xml; charset=utf-8":           XMLCodec,
+		"application/atom+xml":              XMLCodec,
+		"application/x-www-form-urlencoded": FormCodec,
+	} {
+		codec, ok := CodecFor(contentType)
+		assert.ShouldBeTrue(ok)
+		assert.Equal(want, codec)
+	}
+
+	_, ok := CodecFor("text/plain")
+	assert.ShouldBeFalse(ok)
+	_, ok = CodecFor("")
+	assert.ShouldBeFalse(ok)
+
+	RegisterCodec(upperCodec{})
+	codec, ok := CodecFor("text/x-upper; charset=utf-8")
+	assert.ShouldBeTrue(ok)
+	assert.Equal(Codec(upperCodec{}), codec)
+
+	var buf bytes.Buffer
+	assert.IsNil(codec.Encode(&buf, "hello"))
+	var s string
+	assert.IsNil(codec.Decode(&buf, &s))
+	assert.Equal("hello", s)
+
+	// DecodeResponse uses the codec of the response
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": {"text/xml"}},
+		Body:   io.NopCloser(strings.NewReader("<todo><id>3</id><title>decoded</title></todo>")),
+	}
+	var got todo
+	assert.IsNil(NewHttpClient().DecodeResponse(resp, &got))
+	assert.Equal(3, got.Id)
+	assert.Equal("decoded", got.Title)
+}
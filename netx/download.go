@@ -0,0 +1,436 @@
+package netx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sllt/af/filex"
+	"github.com/sllt/af/internal"
+)
+
+// DefaultDownloadChunks is the number of chunks downloaded in parallel when WithChunks is not given.
+const DefaultDownloadChunks = 4
+
+// ErrChecksumMismatch is returned by Download when the downloaded file doesn't have the expected checksum.
+var ErrChecksumMismatch = errors.New("netx: checksum mismatch")
+
+// DownloadOption configures a download.
+type DownloadOption func(*downloadConfig)
+
+type downloadConfig struct {
+	client   *http.Client
+	setTLS   func(rawUrl string)
+	chunks   int
+	shaType  int
+	checksum string
+	progress func(downloaded, total int64)
+}
+
+// WithDownloadClient sends the requests of the download with client instead of http.DefaultClient.
+func WithDownloadClient(client *HttpClient) DownloadOption {
+	return func(c *downloadConfig) {
+		c.client = client.Client
+		c.setTLS = client.setTLS
+	}
+}
+
+// WithChunks sets the number of chunks downloaded in parallel, values below 1 mean 1.
+func WithChunks(n int) DownloadOption {
+	return func(c *downloadConfig) {
+		if n < 1 {
+			n = 1
+		}
+		c.chunks = n
+	}
+}
+
+// WithChecksum verifies the hex encoded sha checksum of the downloaded file, param `shaType`
+// should be 1, 256 or 512, see filex.Sha.
+func WithChecksum(shaType int, checksum string) DownloadOption {
+	return func(c *downloadConfig) {
+		c.shaType = shaType
+		c.checksum = checksum
+	}
+}
+
+// WithProgress sets the function called with the number of bytes downloaded so far and the
+// size of the file, -1 when unknown. It is called by one goroutine at a time.
+func WithProgress(fn func(downloaded, total int64)) DownloadOption {
+	return func(c *downloadConfig) {
+		c.progress = fn
+	}
+}
+
+// downloadState is saved in the sidecar state file to resume a download.
+type downloadState struct {
+	URL          string          `json:"url"`
+	Size         int64           `json:"size"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"lastModified,omitempty"`
+	Chunks       []downloadChunk `json:"chunks"`
+}
+
+// downloadChunk is a range of the file, End is inclusive.
+type downloadChunk struct {
+	Start   int64 `json:"start"`
+	End     int64 `json:"end"`
+	Written int64 `json:"written"`
+}
+
+func (c *downloadChunk) remaining() int64 {
+	return c.End - c.Start + 1 - c.Written
+}
+
+// Download downloads the file of the url to the local file path.
+//
+// When the server supports range requests, the file is downloaded in parallel chunks into
+// path + ".part", and the progress of the chunks is saved in the sidecar file path + ".part.state"
+// when a chunk completes and when Download returns. A later Download of the same url resumes
+// from them, unless the size, ETag or Last-Modified of the remote file changed. A chunk response
+// whose Content-Range is not the requested range fails the download. Otherwise, or when the HEAD
+// request is rejected, the file is downloaded with a single request, from the start.
+//
+// Once complete, the file is verified against the checksum given with WithChecksum, if any, and
+// renamed to path. A file not matching the checksum is removed with its state, and
+// ErrChecksumMismatch is returned.
+func Download(ctx context.Context, rawUrl, path string, opts ...DownloadOption) error {
+	config := &downloadConfig{client: http.DefaultClient, setTLS: func(string) {}, chunks: DefaultDownloadChunks}
+	for _, opt := range opts {
+		opt(config)
+	}
+	config.setTLS(rawUrl)
+
+	d := &download{
+		config:    config,
+		url:       rawUrl,
+		partPath:  path + ".part",
+		statePath: path + ".part.state",
+	}
+	if err := d.run(ctx); err != nil {
+		return err
+	}
+
+	if config.checksum != "" {
+		sum, err := filex.Sha(d.partPath, config.shaType)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(sum, config.checksum) {
+			os.Remove(d.partPath)
+			os.Remove(d.statePath)
+			return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, config.checksum, sum)
+		}
+	}
+
+	if err := os.Rename(d.partPath, path); err != nil {
+		return err
+	}
+	os.Remove(d.statePath)
+	return nil
+}
+
+type download struct {
+	config    *downloadConfig
+	url       string
+	partPath  string
+	statePath string
+
+	// mu guards the written bytes of the chunks, and serializes the progress calls.
+	mu         sync.Mutex
+	state      *downloadState
+	downloaded int64
+
+	saveMu sync.Mutex
+}
+
+// run downloads the file into the part file.
+func (d *download) run(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, d.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.config.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	// some servers don't support HEAD, the file is downloaded without ranges then
+	headFailed := resp.StatusCode < 200 || resp.StatusCode > 299
+	if headFailed || resp.ContentLength < 0 || resp.Header.Get("Accept-Ranges") != "bytes" {
+		if headFailed {
+			resp.ContentLength = -1
+		}
+		os.Remove(d.statePath)
+		return d.fetchAll(ctx, resp.ContentLength)
+	}
+
+	remote := &downloadState{
+		URL:          d.url,
+		Size:         resp.ContentLength,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	d.state = d.loadState(remote)
+
+	file, err := os.OpenFile(d.partPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := file.Truncate(d.state.Size); err != nil {
+		return err
+	}
+
+	for _, c := range d.state.Chunks {
+		d.downloaded += c.Written
+	}
+	d.report(0)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i := range d.state.Chunks {
+		c := &d.state.Chunks[i]
+		if c.remaining() == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := d.fetchChunk(ctx, file, c)
+			if err == nil {
+				err = d.saveState(file)
+			}
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		if err := d.saveState(file); err != nil {
+			return internal.JoinError(firstErr, err)
+		}
+		return firstErr
+	}
+	return file.Sync()
+}
+
+// loadState returns the saved state of the download when it matches the remote file,
+// or a new state splitting the file into chunks.
+func (d *download) loadState(remote *downloadState) *downloadState {
+	if data, err := os.ReadFile(d.statePath); err == nil {
+		var saved downloadState
+		if json.Unmarshal(data, &saved) == nil && saved.URL == remote.URL && saved.Size == remote.Size &&
+			saved.ETag == remote.ETag && saved.LastModified == remote.LastModified && filex.IsExist(d.partPath) {
+			return &saved
+		}
+	}
+	os.Remove(d.partPath)
+
+	n := int64(d.config.chunks)
+	if n > remote.Size {
+		n = remote.Size
+	}
+	if n == 0 {
+		return remote
+	}
+	chunkSize := (remote.Size + n - 1) / n
+	for start := int64(0); start < remote.Size; start += chunkSize {
+		end := start + chunkSize - 1
+		if end >= remote.Size {
+			end = remote.Size - 1
+		}
+		remote.Chunks = append(remote.Chunks, downloadChunk{Start: start, End: end})
+	}
+	return remote
+}
+
+// saveState syncs the part file then writes the state file, so that the state never counts
+// bytes which are not in the part file. The state file is replaced atomically.
+func (d *download) saveState(file *os.File) error {
+	d.saveMu.Lock()
+	defer d.saveMu.Unlock()
+
+	d.mu.Lock()
+	data, err := json.Marshal(d.state)
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	tmp := d.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.statePath)
+}
+
+// fetchChunk downloads the remaining bytes of a chunk with a range request.
+func (d *download) fetchChunk(ctx context.Context, file *os.File, c *downloadChunk) error {
+	d.mu.Lock()
+	offset := c.Start + c.Written
+	d.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, c.End))
+	// a weak ETag can't be used with If-Range
+	if d.state.ETag != "" && !strings.HasPrefix(d.state.ETag, "W/") {
+		req.Header.Set("If-Range", d.state.ETag)
+	} else if d.state.LastModified != "" {
+		req.Header.Set("If-Range", d.state.LastModified)
+	}
+
+	resp, err := d.config.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("netx: download %s: unexpected response status for range %d-%d: %s",
+			d.url, offset, c.End, resp.Status)
+	}
+	// a multipart/byteranges response has no Content-Range, and is rejected too
+	if start, end, ok := parseContentRange(resp.Header.Get("Content-Range")); !ok || start != offset || end != c.End {
+		return fmt.Errorf("netx: download %s: unexpected content range for range %d-%d: %q",
+			d.url, offset, c.End, resp.Header.Get("Content-Range"))
+	}
+
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			d.mu.Lock()
+			remaining := c.remaining()
+			d.mu.Unlock()
+			if int64(n) > remaining {
+				n = int(remaining)
+			}
+
+			if _, werr := file.WriteAt(buf[:n], offset); werr != nil {
+				return werr
+			}
+			offset += int64(n)
+
+			d.mu.Lock()
+			c.Written += int64(n)
+			d.mu.Unlock()
+			d.report(int64(n))
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if c.remaining() != 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// parseContentRange parses the first and last bytes of a Content-Range header, "bytes first-last/size".
+func parseContentRange(value string) (int64, int64, bool) {
+	value, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+	value, _, ok = strings.Cut(value, "/")
+	if !ok {
+		return 0, 0, false
+	}
+	first, last, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// fetchAll downloads the file with a single request, when the server doesn't support range requests.
+func (d *download) fetchAll(ctx context.Context, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.config.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("netx: download %s: unexpected response status: %s", d.url, resp.Status)
+	}
+
+	file, err := os.Create(d.partPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	d.state = &downloadState{Size: size}
+	d.report(0)
+	_, err = io.Copy(file, io.TeeReader(resp.Body, progressWriter(d.report)))
+	if err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// report adds n bytes to the downloaded bytes, and calls the progress function.
+func (d *download) report(n int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.downloaded += n
+	if d.config.progress != nil {
+		d.config.progress(d.downloaded, d.state.Size)
+	}
+}
+
+// progressWriter reports the bytes written to it.
+type progressWriter func(n int64)
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	w(int64(len(p)))
+	return len(p), nil
+}
@@ -0,0 +1,262 @@
+package netx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/cryptor"
+	"github.com/sllt/af/filex"
+	"github.com/sllt/af/internal"
+)
+
+// rangeServer serves content with range requests support, and records the ranges requested.
+type rangeServer struct {
+	content []byte
+
+	mu     sync.Mutex
+	ranges []string
+	// fail handles a range request instead of http.ServeContent when it returns true.
+	fail func(w http.ResponseWriter, r *http.Request) bool
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		s.mu.Lock()
+		s.ranges = append(s.ranges, r.Header.Get("Range"))
+		fail := s.fail
+		s.mu.Unlock()
+		if fail != nil && fail(w, r) {
+			return
+		}
+	}
+	http.ServeContent(w, r, "file.bin", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), bytes.NewReader(s.content))
+}
+
+func randomContent(size int) []byte {
+	content := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(content)
+	return content
+}
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDownload")
+
+	rs := &rangeServer{content: randomContent(1 << 20)}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	var calls int
+	var downloaded, total int64
+	err := Download(context.Background(), server.URL, path,
+		WithChunks(4),
+		WithChecksum(256, strings.ToUpper(cryptor.Sha256(string(rs.content)))),
+		WithProgress(func(d, t int64) {
+			calls++
+			downloaded, total = d, t
+		}),
+	)
+	assert.IsNil(err)
+
+	got, err := os.ReadFile(path)
+	assert.IsNil(err)
+	assert.ShouldBeTrue(bytes.Equal(rs.content, got))
+	assert.ShouldBeFalse(filex.IsExist(path + ".part"))
+	assert.ShouldBeFalse(filex.IsExist(path + ".part.state"))
+
+	assert.Equal(4, len(rs.ranges))
+	assert.Equal(int64(len(rs.content)), downloaded)
+	assert.Equal(int64(len(rs.content)), total)
+	assert.ShouldBeTrue(calls > 4)
+
+	// an empty file
+	rs = &rangeServer{content: []byte{}}
+	server2 := httptest.NewServer(rs)
+	defer server2.Close()
+	assert.IsNil(Download(context.Background(), server2.URL, path))
+	got, err = os.ReadFile(path)
+	assert.IsNil(err)
+	assert.Equal(0, len(got))
+}
+
+func TestDownloadResume(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDownloadResume")
+
+	size := 1 << 20
+	chunk := size / 4
+	rs := &rangeServer{content: randomContent(size)}
+	// the third chunk is interrupted in the middle
+	rs.fail = func(w http.ResponseWriter, r *http.Request) bool {
+		start := 2 * chunk
+		if r.Header.Get("Range") != fmt.Sprintf("bytes=%d-%d", start, start+chunk-1) {
+			return false
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+chunk-1, size))
+		w.Header().Set("Content-Length", fmt.Sprint(chunk))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(rs.content[start : start+chunk/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	checksum := WithChecksum(256, cryptor.Sha256(string(rs.content)))
+	err := Download(context.Background(), server.URL, path, WithChunks(4), checksum)
+	assert.IsNotNil(err)
+	assert.ShouldBeFalse(filex.IsExist(path))
+	assert.ShouldBeTrue(filex.IsExist(path + ".part"))
+	assert.ShouldBeTrue(filex.IsExist(path + ".part.state"))
+
+	// the download resumes where the third chunk stopped
+	rs.mu.Lock()
+	rs.fail = nil
+	rs.ranges = nil
+	rs.mu.Unlock()
+	var first int64 = -1
+	err = Download(context.Background(), server.URL, path, WithChunks(4), checksum,
+		WithProgress(func(downloaded, total int64) {
+			if first < 0 {
+				first = downloaded
+			}
+		}))
+	assert.IsNil(err)
+
+	got, err := os.ReadFile(path)
+	assert.IsNil(err)
+	assert.ShouldBeTrue(bytes.Equal(rs.content, got))
+	assert.ShouldBeFalse(filex.IsExist(path + ".part.state"))
+
+	resumed := fmt.Sprintf("bytes=%d-%d", 2*chunk+chunk/2, 3*chunk-1)
+	found := false
+	for _, r := range rs.ranges {
+		found = found || r == resumed
+	}
+	assert.ShouldBeTrue(found)
+	assert.ShouldBeTrue(first >= int64(chunk/2))
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDownloadChecksumMismatch")
+
+	rs := &rangeServer{content: randomContent(4096)}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	err := Download(context.Background(), server.URL, path, WithChecksum(1, cryptor.Sha1("other")))
+	assert.ShouldBeTrue(errors.Is(err, ErrChecksumMismatch))
+	assert.ShouldBeFalse(filex.IsExist(path))
+	assert.ShouldBeFalse(filex.IsExist(path + ".part"))
+	assert.ShouldBeFalse(filex.IsExist(path + ".part.state"))
+}
+
+func TestDownloadWithoutRange(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDownloadWithoutRange")
+
+	content := randomContent(100000)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requests++
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	var downloaded int64
+	err := Download(context.Background(), server.URL, path, WithProgress(func(d, t int64) {
+		downloaded = d
+	}))
+	assert.IsNil(err)
+	assert.Equal(1, requests)
+	assert.Equal(int64(len(content)), downloaded)
+
+	got, err := os.ReadFile(path)
+	assert.IsNil(err)
+	assert.ShouldBeTrue(bytes.Equal(content, got))
+
+	server.Close()
+	assert.IsNotNil(Download(context.Background(), server.URL, path))
+}
+
+func TestDownloadHeadNotAllowed(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDownloadHeadNotAllowed")
+
+	rs := &rangeServer{content: randomContent(100000)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		rs.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	assert.IsNil(Download(context.Background(), server.URL, path))
+	assert.Equal([]string{""}, rs.ranges)
+
+	got, err := os.ReadFile(path)
+	assert.IsNil(err)
+	assert.ShouldBeTrue(bytes.Equal(rs.content, got))
+}
+
+func TestDownloadUnexpectedContentRange(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestDownloadUnexpectedContentRange")
+
+	size := 4096
+	rs := &rangeServer{content: randomContent(size)}
+	// the server ignores the start of the range
+	rs.fail = func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", size/2-1, size))
+		w.Header().Set("Content-Length", fmt.Sprint(size/2))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(rs.content[:size/2])
+		return true
+	}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	err := Download(context.Background(), server.URL, path, WithChunks(2))
+	assert.IsNotNil(err)
+	assert.ShouldBeTrue(strings.Contains(err.Error(), "unexpected content range for range 2048-4095"))
+	assert.ShouldBeFalse(filex.IsExist(path))
+}
+
+func TestParseContentRange(t *testing.T) {
+	assert := internal.NewAssert(t, "TestParseContentRange")
+
+	start, end, ok := parseContentRange("bytes 100-199/1000")
+	assert.ShouldBeTrue(ok)
+	assert.Equal(int64(100), start)
+	assert.Equal(int64(199), end)
+
+	_, _, ok = parseContentRange("bytes 0-99/*")
+	assert.ShouldBeTrue(ok)
+
+	for _, value := range []string{"", "bytes */1000", "bytes 200-100/1000", "items 0-1/2", "bytes 0-99"} {
+		_, _, ok = parseContentRange(value)
+		assert.ShouldBeFalse(ok)
+	}
+}
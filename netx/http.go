@@ -17,10 +17,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strings"
 	"time"
@@ -94,6 +92,8 @@ type HttpRequest struct {
 	FormData    url.Values
 	File        *File
 	Body        []byte
+	// BodyReader is streamed as the body of the request instead of Body, if not nil.
+	BodyReader io.Reader
 }
 
 // HttpClientConfig contains some configurations for http client
@@ -105,6 +105,8 @@ type HttpClientConfig struct {
 	ResponseTimeout  time.Duration
 	Verbose          bool
 	Proxy            *url.URL
+	// Middlewares wrap the transport of the client, see Chain.
+	Middlewares []Middleware
 }
 
 // defaultHttpClientConfig defalut client config.
@@ -121,19 +123,24 @@ type HttpClient struct {
 	Request *http.Request
 	Config  HttpClientConfig
 	Context context.Context
+
+	// transport is the transport wrapped by the middlewares.
+	transport *http.Transport
 }
 
 // NewHttpClient make a HttpClient instance.
 func NewHttpClient() *HttpClient {
+	transport := &http.Transport{
+		TLSHandshakeTimeout:   defaultHttpClientConfig.HandshakeTimeout,
+		ResponseHeaderTimeout: defaultHttpClientConfig.ResponseTimeout,
+		DisableCompression:    !defaultHttpClientConfig.Compressed,
+	}
 	client := &HttpClient{
 		Client: &http.Client{
-			Transport: &http.Transport{
-				TLSHandshakeTimeout:   defaultHttpClientConfig.HandshakeTimeout,
-				ResponseHeaderTimeout: defaultHttpClientConfig.ResponseTimeout,
-				DisableCompression:    !defaultHttpClientConfig.Compressed,
-			},
+			Transport: transport,
 		},
-		Config: *defaultHttpClientConfig,
+		Config:    *defaultHttpClientConfig,
+		transport: transport,
 	}
 
 	return client
@@ -145,15 +152,17 @@ func NewHttpClientWithConfig(config *HttpClientConfig) *HttpClient {
 		config = defaultHttpClientConfig
 	}
 
+	transport := &http.Transport{
+		TLSHandshakeTimeout:   config.HandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseTimeout,
+		DisableCompression:    !config.Compressed,
+	}
 	client := &HttpClient{
 		Client: &http.Client{
-			Transport: &http.Transport{
-				TLSHandshakeTimeout:   config.HandshakeTimeout,
-				ResponseHeaderTimeout: config.ResponseTimeout,
-				DisableCompression:    !config.Compressed,
-			},
+			Transport: transport,
 		},
-		Config: *config,
+		Config:    *config,
+		transport: transport,
 	}
 
 	if config.SSLEnabled {
@@ -161,10 +170,13 @@ func NewHttpClientWithConfig(config *HttpClientConfig) *HttpClient {
 	}
 
 	if config.Proxy != nil {
-		transport := client.Client.Transport.(*http.Transport)
 		transport.Proxy = http.ProxyURL(config.Proxy)
 	}
 
+	if len(config.Middlewares) > 0 {
+		client.Use(config.Middlewares...)
+	}
+
 	return client
 }
 
@@ -177,10 +189,15 @@ func (client *HttpClient) SendRequest(request *HttpRequest) (*http.Response, err
 
 	rawUrl := request.RawURL
 
-	req, err := http.NewRequest(request.Method, rawUrl, bytes.NewBuffer(request.Body))
+	var body io.Reader = bytes.NewBuffer(request.Body)
+	if request.BodyReader != nil {
+		body = request.BodyReader
+	}
+
+	req, err := http.NewRequest(request.Method, rawUrl, body)
 
 	if client.Context != nil {
-		req, err = http.NewRequestWithContext(client.Context, request.Method, rawUrl, bytes.NewBuffer(request.Body))
+		req, err = http.NewRequestWithContext(client.Context, request.Method, rawUrl, body)
 	}
 
 	if err != nil {
@@ -201,6 +218,9 @@ func (client *HttpClient) SendRequest(request *HttpRequest) (*http.Response, err
 		} else {
 			err = client.setFormData(req, request.FormData, nil)
 		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	client.Request = req
@@ -213,19 +233,27 @@ func (client *HttpClient) SendRequest(request *HttpRequest) (*http.Response, err
 	return resp, nil
 }
 
-// DecodeResponse decode response into target object.
+// DecodeResponse decode response into target object, with the codec of the response
+// Content-Type, see CodecFor. JSON is used when the content type has no codec.
 func (client *HttpClient) DecodeResponse(resp *http.Response, target any) error {
 	if resp == nil {
 		return errors.New("invalid target param")
 	}
 	defer resp.Body.Close()
-	return json.NewDecoder(resp.Body).Decode(target)
+
+	codec, ok := CodecFor(resp.Header.Get("Content-Type"))
+	if !ok {
+		codec = JSONCodec
+	}
+	return codec.Decode(resp.Body, target)
 }
 
 // setTLS set http client transport TLSClientConfig
 func (client *HttpClient) setTLS(rawUrl string) {
 	if strings.HasPrefix(rawUrl, "https") {
-		if transport, ok := client.Client.Transport.(*http.Transport); ok {
+		if client.transport != nil {
+			client.transport.TLSClientConfig = client.TLS
+		} else if transport, ok := client.Client.Transport.(*http.Transport); ok {
 			transport.TLSClientConfig = client.TLS
 		}
 	}
@@ -289,52 +317,22 @@ type File struct {
 	FileName  string
 }
 
-// setFile set parameters for http request formdata file upload
+// setFile set parameters for http request formdata file upload,
+// the file is streamed when the request is sent.
 func setFile(f *File) SetFileFunc {
 	return func(req *http.Request, values url.Values) error {
-		body := &bytes.Buffer{}
-		writer := multipart.NewWriter(body)
-
-		for key, vals := range values {
-			for _, val := range vals {
-				err := writer.WriteField(key, val)
-				if err != nil {
-					return err
-				}
-			}
-		}
-
-		if f.Content != nil {
-			part, err := writer.CreateFormFile(f.FieldName, f.FileName)
-			if err != nil {
-				return err
-			}
-			part.Write(f.Content)
-		} else if f.Path != "" {
-			file, err := os.Open(f.Path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
-
-			part, err := writer.CreateFormFile(f.FieldName, f.FileName)
-			if err != nil {
-				return err
-			}
-			_, err = io.Copy(part, file)
-			if err != nil {
-				return err
-			}
-		}
-
-		err := writer.Close()
+		body := NewMultipartBody(values, f)
+		length, err := body.Len()
 		if err != nil {
 			return err
 		}
 
-		req.Body = io.NopCloser(body)
-		req.Header.Set("Content-Type", writer.FormDataContentType())
-		req.ContentLength = int64(body.Len())
+		req.Body = body.Reader()
+		req.GetBody = func() (io.ReadCloser, error) {
+			return body.Reader(), nil
+		}
+		req.Header.Set("Content-Type", body.ContentType())
+		req.ContentLength = length
 
 		return nil
 	}
@@ -0,0 +1,379 @@
+package netx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sllt/af/breaker"
+	"github.com/sllt/af/ratelimit"
+	"github.com/sllt/af/retry"
+	"github.com/sllt/af/slice"
+)
+
+// Middleware wraps a http.RoundTripper to add a behavior to the requests sent through it.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps rt with the middlewares, the first middleware being the outermost one, so it sees
+// the requests first and the responses last. A nil rt means http.DefaultTransport.
+func Chain(rt http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = middlewares[i](rt)
+	}
+	return rt
+}
+
+// idempotentMethods are the methods retried by default, see RFC 9110 section 9.2.2.
+var idempotentMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete,
+}
+
+// RetryPolicy decides which requests and responses Retry retries.
+type RetryPolicy struct {
+	// Methods are the retried methods, nil means the idempotent methods:
+	// GET, HEAD, OPTIONS, TRACE, PUT and DELETE.
+	Methods []string
+
+	// Retryable decides whether a response or a transport error is retried, nil means
+	// the transport errors and the 429, 502, 503 and 504 status codes.
+	Retryable func(resp *http.Response, err error) bool
+
+	// MaxRetryAfter caps the delay given by the Retry-After header of a response, 0 means no cap.
+	MaxRetryAfter time.Duration
+}
+
+func (p RetryPolicy) retryable(resp *http.Response, err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(resp, err)
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// statusError is returned to retry.Do for a response to retry.
+type statusError struct {
+	req  *http.Request
+	resp *http.Response
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("netx: %s %s: %s", e.req.Method, e.req.URL.Redacted(), e.resp.Status)
+}
+
+// Retry sends the requests again when the policy allows it, with the attempts and backoff
+// configured by the options of the retry package, see retry.Do. The delay given by the
+// Retry-After header of a response overrides the backoff strategy.
+//
+// When the attempts are exhausted on a retryable response, that response is returned without
+// error. A request having a body without GetBody is sent once: its body is not read into memory,
+// so that streamed bodies stay streamed.
+func Retry(policy RetryPolicy, opts ...retry.Option) Middleware {
+	methods := policy.Methods
+	if methods == nil {
+		methods = idempotentMethods
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !slice.Contain(methods, req.Method) || !replayable(req) {
+				return next.RoundTrip(req)
+			}
+
+			// last is the last retryable response, returned when giving up
+			var last *http.Response
+			resp, err := retry.Do(req.Context(), func(ctx context.Context, attempt int) (*http.Response, error) {
+				r := req
+				if attempt > 1 {
+					var err error
+					if r, err = rewind(req); err != nil {
+						return nil, retry.Permanent(err)
+					}
+				}
+				if last != nil {
+					discard(last)
+					last = nil
+				}
+
+				resp, err := next.RoundTrip(r)
+				if !policy.retryable(resp, err) {
+					if err != nil {
+						return nil, retry.Permanent(err)
+					}
+					return resp, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+
+				last = resp
+				err = &statusError{req: r, resp: resp}
+				if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+					if policy.MaxRetryAfter > 0 && delay > policy.MaxRetryAfter {
+						delay = policy.MaxRetryAfter
+					}
+					err = retry.RetryAfter(err, delay)
+				}
+				return nil, err
+			}, opts...)
+
+			if err != nil {
+				var retryErr *retry.RetryError
+				if last != nil && errors.As(err, &retryErr) {
+					var statusErr *statusError
+					if errors.As(retryErr.Last(), &statusErr) && statusErr.resp == last {
+						return last, nil
+					}
+				}
+				if last != nil {
+					discard(last)
+				}
+				return nil, err
+			}
+			return resp, nil
+		})
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// DefaultRedactedHeaders are the headers redacted by Logging when no header is given.
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Logging logs each request and its response, or its error, with logf. The values of the
+// redacted headers are replaced by "[REDACTED]", no header means DefaultRedactedHeaders.
+func Logging(logf func(format string, args ...any), redacted ...string) Middleware {
+	if len(redacted) == 0 {
+		redacted = DefaultRedactedHeaders
+	}
+	redact := make(map[string]bool, len(redacted))
+	for _, name := range redacted {
+		redact[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			url := req.URL.Redacted()
+			logf("--> %s %s %s", req.Method, url, formatHeader(req.Header, redact))
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(start)
+			if err != nil {
+				logf("<-- %s %s error (%s): %v", req.Method, url, elapsed, err)
+				return nil, err
+			}
+
+			logf("<-- %s %s %s (%s) %s", req.Method, url, resp.Status, elapsed, formatHeader(resp.Header, redact))
+			return resp, nil
+		})
+	}
+}
+
+// formatHeader formats the header with sorted keys, replacing the values of the redacted keys.
+func formatHeader(header http.Header, redact map[string]bool) string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	slice.Sort(keys)
+
+	var builder strings.Builder
+	builder.WriteString("{")
+	for i, key := range keys {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		value := strings.Join(header[key], ", ")
+		if redact[http.CanonicalHeaderKey(key)] {
+			value = "[REDACTED]"
+		}
+		builder.WriteString(key)
+		builder.WriteString(": ")
+		builder.WriteString(value)
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+// RateLimit waits until the limiter of the request host, like "example.com:8080", allows the
+// request before sending it. It returns the error of the limiter when the request context is
+// done first.
+func RateLimit(limiter *ratelimit.Keyed[string]) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := limiter.Wait(req.Context(), req.URL.Host); err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// CircuitBreaker sends the requests of each host through its own circuit breaker, created by
+// factory on the first request of the host. The transport errors and the 5xx responses count as
+// failures, a rejected request returns the error of the breaker, like breaker.ErrOpenState.
+func CircuitBreaker(factory func(host string) *breaker.Breaker) Middleware {
+	var (
+		mu       sync.Mutex
+		breakers = make(map[string]*breaker.Breaker)
+	)
+	get := func(host string) *breaker.Breaker {
+		mu.Lock()
+		defer mu.Unlock()
+
+		b, ok := breakers[host]
+		if !ok {
+			b = factory(host)
+			breakers[host] = b
+		}
+		return b
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			done, err := get(req.URL.Host).Allow()
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next.RoundTrip(req)
+			switch {
+			case err != nil:
+				done(err)
+			case resp.StatusCode >= http.StatusInternalServerError:
+				done(&statusError{req: req, resp: resp})
+			default:
+				done(nil)
+			}
+			return resp, err
+		})
+	}
+}
+
+// BeforeRequest calls fn with a clone of each request before sending it, for example to sign
+// it or to set its credentials. The request is not sent when fn returns an error.
+func BeforeRequest(fn func(req *http.Request) error) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			if err := fn(req); err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// AfterResponse calls fn with the response, or the error, of each request, and returns its result.
+func AfterResponse(fn func(req *http.Request, resp *http.Response, err error) (*http.Response, error)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			return fn(req, resp, err)
+		})
+	}
+}
+
+// BearerAuth sets the "Authorization: Bearer" header of each request with the token returned by
+// token, called with refresh false. When the response status is 401 Unauthorized, token is called
+// with refresh true, and the request is sent again once with the new token. A request having a body
+// without GetBody can't be sent again: its 401 response is returned as is.
+func BearerAuth(token func(ctx context.Context, refresh bool) (string, error)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		send := func(req *http.Request, refresh bool) (*http.Response, error) {
+			t, err := token(req.Context(), refresh)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "Bearer "+t)
+			return next.RoundTrip(req)
+		}
+
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := send(req.Clone(req.Context()), false)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || !replayable(req) {
+				return resp, err
+			}
+
+			retried, err := rewind(req)
+			if err != nil {
+				return resp, nil
+			}
+			discard(resp)
+			return send(retried, true)
+		})
+	}
+}
+
+// replayable reports whether req can be sent again: it has no body, or a body which GetBody
+// can read again.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind returns a clone of req with a new body, req must be replayable.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// discard reads a bit of the body of a response not returned to the caller, so that its
+// connection can be reused, and closes it.
+func discard(resp *http.Response) {
+	io.CopyN(io.Discard, resp.Body, 4<<10)
+	resp.Body.Close()
+}
+
+// Use wraps the transport of the client with the middlewares, see Chain.
+func (client *HttpClient) Use(middlewares ...Middleware) {
+	client.Client.Transport = Chain(client.Client.Transport, middlewares...)
+}
@@ -0,0 +1,344 @@
+package netx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/breaker"
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/ratelimit"
+	"github.com/sllt/af/retry"
+)
+
+func TestChain(t *testing.T) {
+	assert := internal.NewAssert(t, "TestChain")
+
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	rt := Chain(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "transport")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}), mark("a"), mark("b"))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	_, err := rt.RoundTrip(req)
+	assert.IsNil(err)
+	assert.Equal([]string{"a", "b", "transport"}, order)
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestRetry")
+
+	var calls int32
+	var bodies []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		if atomic.AddInt32(&calls, 1)%3 != 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Chain(nil, Retry(RetryPolicy{}, retry.RetryWithLinearBackoff(time.Hour)))}
+
+	// the Retry-After header overrides the backoff
+	resp, err := client.Get(server.URL)
+	assert.IsNil(err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal("ok", string(body))
+	assert.Equal(int32(3), atomic.LoadInt32(&calls))
+
+	// POST is not idempotent
+	resp, err = client.Post(server.URL, "text/plain", strings.NewReader("data"))
+	assert.IsNil(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(int32(4), atomic.LoadInt32(&calls))
+
+	// the last response is returned when the attempts are exhausted,
+	// and a body with GetBody is sent again
+	atomic.StoreInt32(&calls, 0)
+	mu.Lock()
+	bodies = nil
+	mu.Unlock()
+	client.Transport = Chain(nil, Retry(RetryPolicy{Methods: []string{http.MethodPost}}, retry.RetryTimes(2)))
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("data"))
+	resp, err = client.Do(req)
+	assert.IsNil(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal([]string{"data", "data"}, bodies)
+
+	// a body without GetBody is streamed once, not read into memory to be sent again
+	atomic.StoreInt32(&calls, 0)
+	mu.Lock()
+	bodies = nil
+	mu.Unlock()
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("streamed"))
+		pw.Close()
+	}()
+	req, _ = http.NewRequest(http.MethodPost, server.URL, pr)
+	resp, err = client.Do(req)
+	assert.IsNil(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal([]string{"streamed"}, bodies)
+
+	// transport errors are retried, until the request is canceled
+	var attempts int32
+	rt := Chain(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&attempts, 1)
+		return nil, errors.New("connection refused")
+	}), Retry(RetryPolicy{}, retry.RetryTimes(3), retry.RetryWithLinearBackoff(time.Millisecond)))
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err = rt.RoundTrip(req)
+	assert.IsNotNil(err)
+	assert.Equal(int32(3), atomic.LoadInt32(&attempts))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	atomic.StoreInt32(&attempts, 0)
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	_, err = rt.RoundTrip(req)
+	assert.ShouldBeTrue(errors.Is(err, context.Canceled))
+	assert.Equal(int32(0), atomic.LoadInt32(&attempts))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert := internal.NewAssert(t, "TestParseRetryAfter")
+
+	delay, ok := parseRetryAfter("120")
+	assert.ShouldBeTrue(ok)
+	assert.Equal(2*time.Minute, delay)
+
+	delay, ok = parseRetryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	assert.ShouldBeTrue(ok)
+	assert.Equal(time.Duration(0), delay)
+
+	delay, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.ShouldBeTrue(ok)
+	assert.ShouldBeTrue(delay > 59*time.Minute)
+
+	for _, invalid := range []string{"", "-1", "soon"} {
+		_, ok = parseRetryAfter(invalid)
+		assert.ShouldBeFalse(ok)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestLogging")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	var logs []string
+	logf := func(format string, args ...any) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+	client := &http.Client{Transport: Chain(nil, Logging(logf))}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/path", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Request-Id", "42")
+	resp, err := client.Do(req)
+	assert.IsNil(err)
+	resp.Body.Close()
+
+	assert.Equal(2, len(logs))
+	assert.Equal("--> GET "+server.URL+"/path {Authorization: [REDACTED], X-Request-Id: 42}", logs[0])
+	assert.ShouldBeTrue(strings.HasPrefix(logs[1], "<-- GET "+server.URL+"/path 418 I'm a teapot"))
+	assert.ShouldBeTrue(strings.Contains(logs[1], "Set-Cookie: [REDACTED]"))
+	assert.ShouldBeFalse(strings.Contains(strings.Join(logs, "\n"), "secret"))
+
+	// custom redacted headers
+	logs = nil
+	client.Transport = Chain(nil, Logging(logf, "x-request-id"))
+	resp, err = client.Do(req)
+	assert.IsNil(err)
+	resp.Body.Close()
+	assert.ShouldBeTrue(strings.Contains(logs[0], "Authorization: Bearer secret, X-Request-Id: [REDACTED]"))
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestRateLimit")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	limiter := ratelimit.NewKeyed[string](func() ratelimit.Limiter {
+		return ratelimit.NewTokenBucket(1, time.Hour, 1)
+	}, 0)
+	client := &http.Client{Transport: Chain(nil, RateLimit(limiter))}
+
+	resp, err := client.Get(server.URL)
+	assert.IsNil(err)
+	resp.Body.Close()
+
+	// the next request of the host is not allowed before an hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	_, err = client.Do(req)
+	assert.IsNotNil(err)
+	assert.Equal(1, limiter.Len())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestCircuitBreaker")
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var hosts []string
+	client := &http.Client{Transport: Chain(nil, CircuitBreaker(func(host string) *breaker.Breaker {
+		hosts = append(hosts, host)
+		return breaker.New(breaker.Options{Name: host, ConsecutiveFailures: 2})
+	}))}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		assert.IsNil(err)
+		resp.Body.Close()
+		assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	_, err := client.Get(server.URL)
+	assert.ShouldBeTrue(errors.Is(err, breaker.ErrOpenState))
+	assert.Equal(int32(2), atomic.LoadInt32(&calls))
+	assert.Equal([]string{strings.TrimPrefix(server.URL, "http://")}, hosts)
+}
+
+func TestBearerAuth(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestBearerAuth")
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	var refreshes int
+	token := "stale"
+	client := &http.Client{Transport: Chain(nil, BearerAuth(func(ctx context.Context, refresh bool) (string, error) {
+		if refresh {
+			refreshes++
+			token = "fresh"
+		}
+		return token, nil
+	}))}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("data"))
+	resp, err := client.Do(req)
+	assert.IsNil(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(1, refreshes)
+	assert.Equal([]string{"data", "data"}, bodies)
+	// the request of the caller is not modified
+	assert.Equal("", req.Header.Get("Authorization"))
+
+	// a body without GetBody is not sent again, the 401 response is returned
+	token = "stale"
+	bodies = nil
+	req, _ = http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("data")))
+	resp, err = client.Do(req)
+	assert.IsNil(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(1, refreshes)
+	assert.Equal([]string{"data"}, bodies)
+	token = "fresh"
+
+	resp, err = client.Get(server.URL)
+	assert.IsNil(err)
+	resp.Body.Close()
+	assert.Equal(1, refreshes)
+
+	client.Transport = Chain(nil, BearerAuth(func(ctx context.Context, refresh bool) (string, error) {
+		return "", errors.New("no token")
+	}))
+	_, err = client.Get(server.URL)
+	assert.IsNotNil(err)
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestHooks")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Signature")))
+	}))
+	defer server.Close()
+
+	var status int
+	client := NewHttpClientWithConfig(&HttpClientConfig{
+		Middlewares: []Middleware{
+			BeforeRequest(func(req *http.Request) error {
+				req.Header.Set("X-Signature", "signed")
+				return nil
+			}),
+			AfterResponse(func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+				if err == nil {
+					status = resp.StatusCode
+				}
+				return resp, err
+			}),
+		},
+	})
+
+	resp, err := client.SendRequest(&HttpRequest{RawURL: server.URL, Method: http.MethodGet})
+	assert.IsNil(err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal("signed", string(body))
+	assert.Equal(http.StatusOK, status)
+
+	client.Use(BeforeRequest(func(req *http.Request) error {
+		return errors.New("unsigned")
+	}))
+	_, err = client.SendRequest(&HttpRequest{RawURL: server.URL, Method: http.MethodGet})
+	assert.IsNotNil(err)
+}
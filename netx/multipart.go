@@ -0,0 +1,115 @@
+package netx
+
+import (
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+)
+
+// MultipartBody is a multipart/form-data body streamed from its values and files, so that large
+// files are uploaded without being held in memory.
+type MultipartBody struct {
+	values   url.Values
+	files    []*File
+	boundary string
+}
+
+// NewMultipartBody creates a multipart body of the form values and files. The files are read from
+// their Path when their Content is nil, and skipped when they have neither Content nor Path.
+func NewMultipartBody(values url.Values, files ...*File) *MultipartBody {
+	return &MultipartBody{
+		values:   values,
+		files:    files,
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+	}
+}
+
+// ContentType returns the content type of the body, with its boundary.
+func (b *MultipartBody) ContentType() string {
+	return b.writer(io.Discard).FormDataContentType()
+}
+
+// Len returns the length in bytes of the body, computed without reading the files.
+// It returns an error when a file can't be found.
+func (b *MultipartBody) Len() (int64, error) {
+	counter := &countingWriter{}
+	err := b.write(counter, func(part io.Writer, f *File) error {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return err
+		}
+		counter.n += info.Size()
+		return nil
+	})
+	return counter.n, err
+}
+
+// Reader returns a new reader of the body, which is written by another goroutine as it is read.
+// A file read error is returned by the reader, the reader must be closed when not read to the end.
+func (b *MultipartBody) Reader() io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(b.write(pw, func(part io.Writer, f *File) error {
+			file, err := os.Open(f.Path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = io.Copy(part, file)
+			return err
+		}))
+	}()
+	return pr
+}
+
+func (b *MultipartBody) writer(w io.Writer) *multipart.Writer {
+	writer := multipart.NewWriter(w)
+	// the boundary is valid since it was generated by multipart.Writer
+	_ = writer.SetBoundary(b.boundary)
+	return writer
+}
+
+// write writes the body to w, with copyFile writing to their part the files read from their path.
+func (b *MultipartBody) write(w io.Writer, copyFile func(part io.Writer, f *File) error) error {
+	writer := b.writer(w)
+
+	for key, vals := range b.values {
+		for _, val := range vals {
+			if err := writer.WriteField(key, val); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range b.files {
+		if f == nil || (f.Content == nil && f.Path == "") {
+			continue
+		}
+
+		part, err := writer.CreateFormFile(f.FieldName, f.FileName)
+		if err != nil {
+			return err
+		}
+		if f.Content != nil {
+			_, err = part.Write(f.Content)
+		} else {
+			err = copyFile(part, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
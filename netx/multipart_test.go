@@ -0,0 +1,74 @@
+package netx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sllt/af/internal"
+)
+
+func TestMultipartBody(t *testing.T) {
+	t.Parallel()
+	assert := internal.NewAssert(t, "TestMultipartBody")
+
+	path := filepath.Join(t.TempDir(), "large.bin")
+	content := strings.Repeat("0123456789", 100000)
+	assert.IsNil(os.WriteFile(path, []byte(content), 0o644))
+
+	body := NewMultipartBody(url.Values{"name": {"large"}},
+		&File{Path: path, FieldName: "file", FileName: "large.bin"},
+		&File{Content: []byte("small"), FieldName: "other", FileName: "small.txt"},
+		&File{FieldName: "skipped"},
+	)
+
+	// the length is computed without reading the file
+	length, err := body.Len()
+	assert.IsNil(err)
+	data, err := io.ReadAll(body.Reader())
+	assert.IsNil(err)
+	assert.Equal(int64(len(data)), length)
+	assert.ShouldBeTrue(strings.HasPrefix(body.ContentType(), "multipart/form-data; boundary="))
+
+	var contentLength int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		received, _ := io.ReadAll(file)
+		w.Write([]byte(r.FormValue("name") + ":" + string(received[:10])))
+	}))
+	defer server.Close()
+
+	result, err := Do[*MultipartBody, string, any](context.Background(), nil, http.MethodPost, server.URL, body,
+		WithCodec(upperCodec{}))
+	assert.IsNil(err)
+	assert.Equal("large:0123456789", result)
+	assert.Equal(length, contentLength)
+
+	// a missing file fails before sending
+	_, err = NewMultipartBody(nil, &File{Path: filepath.Join(t.TempDir(), "missing"), FieldName: "file"}).Len()
+	assert.IsNotNil(err)
+
+	_, err = NewHttpClient().SendRequest(&HttpRequest{
+		RawURL:   server.URL,
+		Method:   http.MethodPost,
+		FormData: url.Values{"name": {"missing"}},
+		File:     &File{Path: filepath.Join(t.TempDir(), "missing"), FieldName: "file"},
+	})
+	assert.IsNotNil(err)
+}
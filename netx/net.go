@@ -222,6 +222,7 @@ func UploadFile(filepath string, server string) (bool, error) {
 }
 
 // DownloadFile will download the file exist in url to a local file.
+// See Download for parallel, resumable and verified downloads.
 func DownloadFile(filepath string, url string) error {
 	resp, err := http.Get(url)
 	if err != nil {
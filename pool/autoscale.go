@@ -0,0 +1,273 @@
+package pool
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultAutoscaleInterval is the period between two autoscaler decisions.
+	DefaultAutoscaleInterval = time.Second
+
+	// DefaultAutoscaleCooldown is the minimum time between two capacity changes of the autoscaler.
+	DefaultAutoscaleCooldown = 5 * time.Second
+)
+
+// AutoscaleSample is the pool activity observed by the autoscaler during one period.
+type AutoscaleSample struct {
+	// Cap is the current capacity of the pool.
+	Cap int
+
+	// Busy is the number of tasks being executed.
+	Busy int
+
+	// Waiting is the number of invokers blocked on a worker.
+	Waiting int
+
+	// Utilization is Busy / Cap.
+	Utilization float64
+
+	// Submitted is the number of tasks handed to a worker during the period.
+	Submitted uint64
+
+	// Rejected is the number of tasks rejected with ErrPoolOverload during the period.
+	Rejected uint64
+
+	// QueueWait is the mean time spent waiting for a worker by the tasks submitted during the period.
+	QueueWait time.Duration
+}
+
+// congested indicates whether the invokers had to wait for a worker during the period.
+func (s AutoscaleSample) congested(maxQueueWait time.Duration) bool {
+	return s.Waiting > 0 || s.Rejected > 0 || s.QueueWait > maxQueueWait
+}
+
+// ScalingPolicy computes the capacity of the pool from the activity of the last period.
+// The result is bounded by AutoscaleOptions.Min and AutoscaleOptions.Max.
+type ScalingPolicy interface {
+	Next(sample AutoscaleSample) int
+}
+
+// ScalingPolicyFunc is an adapter to use an ordinary function as a ScalingPolicy.
+type ScalingPolicyFunc func(sample AutoscaleSample) int
+
+// Next implements ScalingPolicy.
+func (f ScalingPolicyFunc) Next(sample AutoscaleSample) int {
+	return f(sample)
+}
+
+// AIMDPolicy increases the capacity additively while the invokers are waiting for workers,
+// and decreases it multiplicatively while the pool is underused.
+type AIMDPolicy struct {
+	// Increase is the number of workers added on congestion, 0 means 1.
+	Increase int
+
+	// Decrease is the factor applied to the capacity when the pool is underused, 0 means 0.5.
+	Decrease float64
+
+	// MaxQueueWait is the mean queue wait above which the pool is congested, 0 means any wait.
+	MaxQueueWait time.Duration
+
+	// LowUtilization is the utilization below which the pool is underused, 0 means 0.5.
+	LowUtilization float64
+}
+
+// Next implements ScalingPolicy.
+func (p AIMDPolicy) Next(s AutoscaleSample) int {
+	increase := p.Increase
+	if increase <= 0 {
+		increase = 1
+	}
+	decrease := p.Decrease
+	if decrease <= 0 || decrease >= 1 {
+		decrease = 0.5
+	}
+	low := p.LowUtilization
+	if low <= 0 {
+		low = 0.5
+	}
+
+	switch {
+	case s.congested(p.MaxQueueWait):
+		return s.Cap + increase
+	case s.Utilization < low:
+		return int(float64(s.Cap) * decrease)
+	default:
+		return s.Cap
+	}
+}
+
+// TargetLatencyPolicy sizes the pool to keep the mean queue wait under a target.
+// The capacity grows in proportion to the ratio between the observed queue wait and the target,
+// by one worker at least while invokers are blocked, and shrinks to the number of busy workers, with some headroom, when the target is met.
+type TargetLatencyPolicy struct {
+	// Target is the mean queue wait to stay under.
+	Target time.Duration
+
+	// MaxGrowth is the maximum factor applied to the capacity in one step, 0 means 2.
+	MaxGrowth float64
+
+	// TargetUtilization is the utilization aimed at when the pool shrinks, 0 means 0.75.
+	TargetUtilization float64
+}
+
+// Next implements ScalingPolicy.
+func (p TargetLatencyPolicy) Next(s AutoscaleSample) int {
+	maxGrowth := p.MaxGrowth
+	if maxGrowth <= 1 {
+		maxGrowth = 2
+	}
+	utilization := p.TargetUtilization
+	if utilization <= 0 || utilization > 1 {
+		utilization = 0.75
+	}
+
+	if s.congested(p.Target) {
+		growth := 1.0
+		if p.Target > 0 && s.QueueWait > p.Target {
+			growth = math.Min(float64(s.QueueWait)/float64(p.Target), maxGrowth)
+		}
+		return int(math.Max(math.Ceil(float64(s.Cap)*growth), float64(s.Cap+1)))
+	}
+
+	next := int(math.Ceil(float64(s.Busy) / utilization))
+	if next > s.Cap {
+		return s.Cap
+	}
+	return next
+}
+
+// AutoscaleOptions configures the autoscaler of a pool.
+//
+// The autoscaler only changes the capacity with Tune: when the capacity shrinks, the workers
+// above it are not put back into the worker queue, and the idle ones are stopped by the scavenger
+// after ExpiryDuration, as usual.
+type AutoscaleOptions struct {
+	// Min is the minimum capacity of the pool, it must be positive.
+	Min int
+
+	// Max is the maximum capacity of the pool, it must be greater than or equal to Min.
+	Max int
+
+	// Interval is the period between two decisions, 0 means DefaultAutoscaleInterval.
+	Interval time.Duration
+
+	// ScaleUpCooldown is the minimum time after a capacity change before growing the pool,
+	// 0 means Interval.
+	ScaleUpCooldown time.Duration
+
+	// ScaleDownCooldown is the minimum time after a capacity change before shrinking the pool,
+	// 0 means DefaultAutoscaleCooldown.
+	ScaleDownCooldown time.Duration
+
+	// Policy computes the next capacity, nil means AIMDPolicy{}.
+	Policy ScalingPolicy
+
+	// OnScale is called after each capacity change, if not nil.
+	OnScale func(from, to int, sample AutoscaleSample)
+}
+
+// scalable is the part of the pools driven by the autoscaler.
+type scalable interface {
+	Stats() Stats
+	Tune(size int)
+}
+
+// validate checks the bounds of the autoscaler against the pool, and fills the default values.
+func (o *AutoscaleOptions) validate(size int, preAlloc bool) error {
+	if o.Min <= 0 || o.Max < o.Min || size == -1 || preAlloc {
+		return ErrInvalidAutoscale
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultAutoscaleInterval
+	}
+	if o.ScaleUpCooldown <= 0 {
+		o.ScaleUpCooldown = o.Interval
+	}
+	if o.ScaleDownCooldown <= 0 {
+		o.ScaleDownCooldown = DefaultAutoscaleCooldown
+	}
+	if o.Policy == nil {
+		o.Policy = AIMDPolicy{}
+	}
+	return nil
+}
+
+// clamp bounds a capacity between Min and Max.
+func (o *AutoscaleOptions) clamp(size int) int {
+	if size < o.Min {
+		return o.Min
+	}
+	if size > o.Max {
+		return o.Max
+	}
+	return size
+}
+
+// autoscale adjusts the capacity of the pool periodically, it runs in an individual goroutine.
+func autoscale(ctx context.Context, p scalable, opts *AutoscaleOptions, done *int32) {
+	ticker := time.NewTicker(opts.Interval)
+	defer func() {
+		ticker.Stop()
+		atomic.StoreInt32(done, 1)
+	}()
+
+	prev := p.Stats()
+	var lastChange time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats := p.Stats()
+		sample := newAutoscaleSample(prev, stats)
+		prev = stats
+
+		next := opts.clamp(opts.Policy.Next(sample))
+		if next == sample.Cap {
+			continue
+		}
+
+		cooldown := opts.ScaleDownCooldown
+		if next > sample.Cap {
+			cooldown = opts.ScaleUpCooldown
+		}
+		if !lastChange.IsZero() && time.Since(lastChange) < cooldown {
+			continue
+		}
+
+		p.Tune(next)
+		lastChange = time.Now()
+		if opts.OnScale != nil {
+			opts.OnScale(sample.Cap, next, sample)
+		}
+	}
+}
+
+// newAutoscaleSample computes the activity between two snapshots.
+func newAutoscaleSample(prev, current Stats) AutoscaleSample {
+	busy := int(current.Submitted - current.Completed - current.Panicked)
+	if busy < 0 {
+		busy = 0
+	}
+
+	s := AutoscaleSample{
+		Cap:       current.Cap,
+		Busy:      busy,
+		Waiting:   current.Waiting,
+		Submitted: current.Submitted - prev.Submitted,
+		Rejected:  current.Rejected - prev.Rejected,
+	}
+	if current.Cap > 0 {
+		s.Utilization = float64(busy) / float64(current.Cap)
+	}
+	if n := current.QueueWait.Count - prev.QueueWait.Count; n > 0 {
+		s.QueueWait = (current.QueueWait.Sum - prev.QueueWait.Sum) / time.Duration(n)
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+)
+
+// Future represents the eventual result of a task submitted with Go.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Go submits a task returning a value to the pool, and returns a future of its result.
+//
+// If the task cannot be submitted, or if ctx is done before the task starts, the future
+// is rejected with the corresponding error. If the task panics, the future is rejected
+// and the panic is handled by the pool as usual.
+func Go[T any](p *Pool, ctx context.Context, task func(ctx context.Context) (T, error)) *Future[T] {
+	f := newFuture[T]()
+
+	err := p.SubmitCtx(ctx, func(ctx context.Context) error {
+		return f.run(ctx, task)
+	})
+	if err != nil {
+		f.complete(f.value, err)
+	}
+
+	return f
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+// run executes the task and completes the future with its result.
+// If the task panics, the future is rejected before the panic is raised again.
+func (f *Future[T]) run(ctx context.Context, task func(ctx context.Context) (T, error)) error {
+	if err := ctx.Err(); err != nil {
+		f.complete(f.value, err)
+		return err
+	}
+
+	completed := false
+	defer func() {
+		if !completed {
+			r := recover()
+			f.complete(f.value, fmt.Errorf("%w: %v", ErrTaskPanicked, r))
+			panic(r)
+		}
+	}()
+
+	value, err := task(ctx)
+	completed = true
+	f.complete(value, err)
+	return err
+}
+
+func (f *Future[T]) complete(value T, err error) {
+	f.value = value
+	f.err = err
+	close(f.done)
+}
+
+// Await blocks until the task is done, and returns its result.
+func (f *Future[T]) Await() (T, error) {
+	<-f.done
+	return f.value, f.err
+}
+
+// AwaitCtx blocks until the task is done or ctx is done.
+func (f *Future[T]) AwaitCtx(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done returns a channel that is closed when the task is done.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
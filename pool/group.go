@@ -0,0 +1,104 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sllt/af/internal"
+)
+
+// Group waits for a batch of tasks running on a pool, and collects their errors.
+// Unlike errgroup.Group, the number of tasks running at once is bounded by the pool:
+// Group.Go blocks while the pool is full.
+type Group struct {
+	pool *Pool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	wg sync.WaitGroup
+
+	mu     sync.Mutex
+	errs   []error
+	failed bool
+}
+
+// NewGroup creates a group of tasks running on the pool.
+func NewGroup(p *Pool) *Group {
+	return &Group{pool: p, ctx: context.Background()}
+}
+
+// NewGroupWithContext creates a group of tasks running on the pool, and a derived context.
+// The derived context is canceled the first time a task returns an error, or when Wait returns.
+func NewGroupWithContext(ctx context.Context, p *Pool) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{pool: p, ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go submits a task to the pool, it blocks until a worker is available.
+// The task receives the group context. Tasks that cannot run because the group
+// context has been canceled after a failure are skipped silently.
+func (g *Group) Go(task func(ctx context.Context) error) {
+	g.wg.Add(1)
+
+	err := g.pool.SubmitCtx(g.ctx, func(ctx context.Context) error {
+		defer g.wg.Done()
+
+		if err := ctx.Err(); err != nil {
+			g.fail(err)
+			return err
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				r := recover()
+				g.fail(fmt.Errorf("%w: %v", ErrTaskPanicked, r))
+				panic(r)
+			}
+		}()
+
+		err := task(ctx)
+		completed = true
+		g.fail(err)
+		return err
+	})
+	if err != nil {
+		g.fail(err)
+		g.wg.Done()
+	}
+}
+
+// Wait blocks until all the tasks are done, and returns their errors joined, or nil.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return internal.JoinError(g.errs...)
+}
+
+func (g *Group) fail(err error) {
+	if err == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// the context error is a consequence of a previous failure
+	if g.failed && g.cancel != nil && err == g.ctx.Err() {
+		return
+	}
+
+	g.failed = true
+	g.errs = append(g.errs, err)
+	if g.cancel != nil {
+		g.cancel()
+	}
+}
@@ -0,0 +1,229 @@
+package pool
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultKeyedLanes is the number of lanes of a KeyedPool created without lanes.
+const DefaultKeyedLanes = 64
+
+// keyedResumeRetries is the number of times the resumption of a lane is retried while the pool
+// is overloaded, before its tasks are dropped.
+const keyedResumeRetries = 10
+
+// KeyedStats is a snapshot of the lanes of a KeyedPool.
+type KeyedStats struct {
+	// Lanes is the number of lanes.
+	Lanes int
+
+	// Active is the number of lanes having a task running.
+	Active int
+
+	// Queued is the number of tasks waiting in the lanes.
+	Queued int
+
+	// MaxDepth is the number of tasks waiting in the deepest lane.
+	MaxDepth int
+
+	// Depths is the number of tasks waiting in each lane.
+	Depths []int
+
+	// Rejected is the number of tasks rejected because their lane was full.
+	Rejected uint64
+
+	// Dropped is the number of accepted tasks dropped because their lane could not resume on a
+	// worker, after a task panicked.
+	Dropped uint64
+}
+
+// keyedLane runs its tasks one at a time, in submission order.
+type keyedLane struct {
+	mu      sync.Mutex
+	tasks   *list.List
+	running bool
+
+	// start is set while the lane waits for a worker, the tasks submitted meanwhile wait for it.
+	start *keyedStart
+}
+
+// keyedStart is the submission of the drain of an idle lane to the pool.
+type keyedStart struct {
+	done chan struct{}
+	err  error
+}
+
+// KeyedPool runs the tasks having the same key sequentially, in submission order,
+// while the tasks having different keys run in parallel on a shared pool.
+//
+// Keys are hashed to a fixed number of serial lanes: a lane holds one worker of the
+// pool while it has tasks, no goroutine is dedicated to a key. Tasks of different keys
+// sharing a lane are serialized too.
+type KeyedPool struct {
+	pool  *Pool
+	seed  maphash.Seed
+	lanes []*keyedLane
+
+	// maxDepth is the maximum number of tasks waiting in a lane, 0 means no limit.
+	maxDepth int
+
+	rejected uint64
+	dropped  uint64
+}
+
+// NewKeyedPool creates a keyed pool running its tasks on p, with the given number of lanes.
+// The number of tasks waiting in a lane is bounded by the MaxBlockingTasks option of p.
+func NewKeyedPool(p *Pool, lanes int) *KeyedPool {
+	if lanes <= 0 {
+		lanes = DefaultKeyedLanes
+	}
+
+	kp := &KeyedPool{
+		pool:     p,
+		seed:     maphash.MakeSeed(),
+		lanes:    make([]*keyedLane, lanes),
+		maxDepth: p.options.MaxBlockingTasks,
+	}
+	for i := range kp.lanes {
+		kp.lanes[i] = &keyedLane{tasks: list.New()}
+	}
+	return kp
+}
+
+// SubmitKeyed submits a task to the lane of the key.
+//
+// It returns ErrPoolOverload when the lane is full, and the error of Pool.Submit when the lane
+// cannot get a worker: then the tasks submitted to the lane while it was waiting for the worker
+// fail with the same error. It blocks while the lane waits for a worker of a full pool.
+func (p *KeyedPool) SubmitKeyed(key string, task func()) error {
+	if p.pool.IsClosed() {
+		return ErrPoolClosed
+	}
+
+	l := p.lanes[maphash.String(p.seed, key)%uint64(len(p.lanes))]
+
+	l.mu.Lock()
+	if p.maxDepth > 0 && l.tasks.Len() >= p.maxDepth {
+		l.mu.Unlock()
+		atomic.AddUint64(&p.rejected, 1)
+		return ErrPoolOverload
+	}
+	l.tasks.PushBack(task)
+	if l.running {
+		start := l.start
+		l.mu.Unlock()
+		if start == nil {
+			return nil
+		}
+		<-start.done
+		return start.err
+	}
+	start := &keyedStart{done: make(chan struct{})}
+	l.running = true
+	l.start = start
+	l.mu.Unlock()
+
+	err := p.pool.Submit(func() { p.drain(l) })
+
+	l.mu.Lock()
+	if l.start == start {
+		l.start = nil
+	}
+	if err != nil {
+		// No worker drains the lane, every task queued since it was idle is dropped.
+		l.tasks.Init()
+		l.running = false
+	}
+	start.err = err
+	l.mu.Unlock()
+	close(start.done)
+	return err
+}
+
+// Stats returns a snapshot of the lanes.
+func (p *KeyedPool) Stats() KeyedStats {
+	s := KeyedStats{
+		Lanes:    len(p.lanes),
+		Depths:   make([]int, len(p.lanes)),
+		Rejected: atomic.LoadUint64(&p.rejected),
+		Dropped:  atomic.LoadUint64(&p.dropped),
+	}
+	for i, l := range p.lanes {
+		l.mu.Lock()
+		depth, running := l.tasks.Len(), l.running
+		l.mu.Unlock()
+
+		s.Depths[i] = depth
+		s.Queued += depth
+		if depth > s.MaxDepth {
+			s.MaxDepth = depth
+		}
+		if running {
+			s.Active++
+		}
+	}
+	return s
+}
+
+// drain runs the tasks of the lane until it is empty, it runs on a worker of the pool.
+func (p *KeyedPool) drain(l *keyedLane) {
+	for {
+		l.mu.Lock()
+		e := l.tasks.Front()
+		if e == nil {
+			l.running = false
+			l.mu.Unlock()
+			return
+		}
+		l.tasks.Remove(e)
+		l.mu.Unlock()
+
+		p.run(l, e.Value.(func()))
+	}
+}
+
+// run executes a task of the lane. If the task panics, the panic is handled by the pool
+// as usual, and the rest of the lane is resumed on another worker.
+func (p *KeyedPool) run(l *keyedLane, task func()) {
+	completed := false
+	defer func() {
+		if !completed {
+			// The current worker is still held, the submission must not wait for it.
+			go p.resume(l)
+		}
+	}()
+
+	task()
+	completed = true
+}
+
+// resume drains the lane on a new worker. The tasks of the lane were accepted already, so the
+// submission is retried a few times while the pool is overloaded; they are dropped once the pool
+// is closed or the retries are exhausted.
+func (p *KeyedPool) resume(l *keyedLane) {
+	backoff := time.Millisecond
+	for i := 0; !p.pool.IsClosed(); i++ {
+		err := p.pool.Submit(func() { p.drain(l) })
+		if err == nil {
+			return
+		}
+		if err != ErrPoolOverload || i == keyedResumeRetries {
+			break
+		}
+
+		time.Sleep(backoff)
+		if backoff < 100*time.Millisecond {
+			backoff *= 2
+		}
+	}
+
+	// No worker drains the lane, the tasks queued on it are dropped.
+	l.mu.Lock()
+	atomic.AddUint64(&p.dropped, uint64(l.tasks.Len()))
+	l.tasks.Init()
+	l.running = false
+	l.mu.Unlock()
+}
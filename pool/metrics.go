@@ -0,0 +1,211 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHistogramBounds are the upper bounds of the buckets of the queue-wait and execution-time histograms.
+var DefaultHistogramBounds = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// MetricsHook is notified of the pool events, it can be used to export metrics to Prometheus, expvar, etc.
+// The methods are called synchronously from the submitting and worker goroutines, so they must be fast
+// and safe for concurrent use.
+type MetricsHook interface {
+	// TaskSubmitted is called when a task is handed to a worker, with the time spent waiting for the worker.
+	TaskSubmitted(queueWait time.Duration)
+
+	// TaskRejected is called when a task is rejected with ErrPoolOverload.
+	TaskRejected()
+
+	// TaskDone is called when a task returns or panics, with its execution time.
+	TaskDone(execTime time.Duration, panicked bool)
+
+	// WorkerSpawned is called when a new worker goroutine is started.
+	WorkerSpawned()
+
+	// WorkersExpired is called when the scavenger stops idle workers.
+	WorkersExpired(n int)
+}
+
+// Histogram is a snapshot of a duration histogram.
+type Histogram struct {
+	// Bounds are the upper bounds (inclusive) of the buckets.
+	Bounds []time.Duration
+
+	// Counts are the number of observations per bucket, it has one more item
+	// than Bounds for the observations greater than the last bound.
+	Counts []uint64
+
+	// Count is the total number of observations.
+	Count uint64
+
+	// Sum is the sum of the observations.
+	Sum time.Duration
+}
+
+// Mean returns the average observation, or 0 when the histogram is empty.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Stats is a snapshot of the pool state and of its cumulative counters.
+//
+// It can be published with expvar:
+//
+//	expvar.Publish("pool", expvar.Func(func() any { return p.Stats() }))
+type Stats struct {
+	Cap     int
+	Running int
+	Free    int
+	Waiting int
+
+	// Submitted is the number of tasks handed to a worker.
+	Submitted uint64
+	// Completed is the number of tasks that returned.
+	Completed uint64
+	// Panicked is the number of tasks that panicked.
+	Panicked uint64
+	// Rejected is the number of tasks rejected with ErrPoolOverload.
+	Rejected uint64
+
+	// WorkersSpawned is the number of worker goroutines started.
+	WorkersSpawned uint64
+	// WorkersExpired is the number of idle workers stopped by the scavenger.
+	WorkersExpired uint64
+
+	// QueueWait is the time spent by the submitters waiting for a worker.
+	QueueWait Histogram
+	// ExecTime is the execution time of the tasks.
+	ExecTime Histogram
+}
+
+// histogram is a lock-free duration histogram.
+type histogram struct {
+	bounds []time.Duration
+	counts []uint64
+	count  uint64
+	sum    int64
+}
+
+func newHistogram(bounds []time.Duration) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(d))
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{
+		Bounds: append([]time.Duration{}, h.bounds...),
+		Counts: make([]uint64, len(h.counts)),
+		Count:  atomic.LoadUint64(&h.count),
+		Sum:    time.Duration(atomic.LoadInt64(&h.sum)),
+	}
+	for i := range h.counts {
+		s.Counts[i] = atomic.LoadUint64(&h.counts[i])
+	}
+	return s
+}
+
+// poolMetrics holds the cumulative counters of a pool.
+type poolMetrics struct {
+	submitted uint64
+	completed uint64
+	panicked  uint64
+	rejected  uint64
+
+	spawned uint64
+	expired uint64
+
+	queueWait *histogram
+	execTime  *histogram
+
+	hook MetricsHook
+}
+
+func newPoolMetrics(hook MetricsHook) *poolMetrics {
+	return &poolMetrics{
+		queueWait: newHistogram(DefaultHistogramBounds),
+		execTime:  newHistogram(DefaultHistogramBounds),
+		hook:      hook,
+	}
+}
+
+func (m *poolMetrics) taskSubmitted(queueWait time.Duration) {
+	atomic.AddUint64(&m.submitted, 1)
+	m.queueWait.observe(queueWait)
+	if m.hook != nil {
+		m.hook.TaskSubmitted(queueWait)
+	}
+}
+
+func (m *poolMetrics) taskRejected() {
+	atomic.AddUint64(&m.rejected, 1)
+	if m.hook != nil {
+		m.hook.TaskRejected()
+	}
+}
+
+func (m *poolMetrics) taskDone(execTime time.Duration, panicked bool) {
+	if panicked {
+		atomic.AddUint64(&m.panicked, 1)
+	} else {
+		atomic.AddUint64(&m.completed, 1)
+	}
+	m.execTime.observe(execTime)
+	if m.hook != nil {
+		m.hook.TaskDone(execTime, panicked)
+	}
+}
+
+func (m *poolMetrics) workerSpawned() {
+	atomic.AddUint64(&m.spawned, 1)
+	if m.hook != nil {
+		m.hook.WorkerSpawned()
+	}
+}
+
+func (m *poolMetrics) workersExpired(n int) {
+	if n == 0 {
+		return
+	}
+	atomic.AddUint64(&m.expired, uint64(n))
+	if m.hook != nil {
+		m.hook.WorkersExpired(n)
+	}
+}
+
+// stats fills the cumulative counters of a snapshot.
+func (m *poolMetrics) stats(s Stats) Stats {
+	s.Submitted = atomic.LoadUint64(&m.submitted)
+	s.Completed = atomic.LoadUint64(&m.completed)
+	s.Panicked = atomic.LoadUint64(&m.panicked)
+	s.Rejected = atomic.LoadUint64(&m.rejected)
+	s.WorkersSpawned = atomic.LoadUint64(&m.spawned)
+	s.WorkersExpired = atomic.LoadUint64(&m.expired)
+	s.QueueWait = m.queueWait.snapshot()
+	s.ExecTime = m.execTime.snapshot()
+	return s
+}
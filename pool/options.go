@@ -42,6 +42,19 @@ type Options struct {
 
 	// When DisablePurge is true, workers are not purged and are resident.
 	DisablePurge bool
+
+	// PriorityAging is the waiting time for a blocked task to gain one priority level,
+	// it prevents the tasks with a low priority from starving.
+	// 0 (default value) means DefaultPriorityAging, a negative value disables aging.
+	PriorityAging time.Duration
+
+	// MetricsHook is notified of the pool events, in addition to the counters returned by Stats.
+	MetricsHook MetricsHook
+
+	// Autoscale enables the autoscaler, which adjusts the capacity of the pool between
+	// a minimum and a maximum, according to the queue wait time and the utilization.
+	// nil (default value) means a fixed capacity.
+	Autoscale *AutoscaleOptions
 }
 
 // WithOptions accepts the whole options config.
@@ -99,3 +112,24 @@ func WithDisablePurge(disable bool) Option {
 		opts.DisablePurge = disable
 	}
 }
+
+// WithPriorityAging sets up the waiting time for a blocked task to gain one priority level.
+func WithPriorityAging(aging time.Duration) Option {
+	return func(opts *Options) {
+		opts.PriorityAging = aging
+	}
+}
+
+// WithMetricsHook sets up a hook notified of the pool events.
+func WithMetricsHook(hook MetricsHook) Option {
+	return func(opts *Options) {
+		opts.MetricsHook = hook
+	}
+}
+
+// WithAutoscale sets up the autoscaler of the pool.
+func WithAutoscale(autoscale AutoscaleOptions) Option {
+	return func(opts *Options) {
+		opts.Autoscale = &autoscale
+	}
+}
@@ -15,6 +15,12 @@ const (
 
 	// DefaultCleanIntervalTime is the interval time to clean up goroutines.
 	DefaultCleanIntervalTime = time.Second
+
+	// DefaultPriority is the priority of the tasks submitted without priority.
+	DefaultPriority = 0
+
+	// DefaultPriorityAging is the waiting time for a blocked task to gain one priority level.
+	DefaultPriorityAging = time.Second
 )
 
 const (
@@ -44,6 +50,12 @@ var (
 	// ErrTimeout will be returned after the operations timed out.
 	ErrTimeout = errors.New("operation timed out")
 
+	// ErrInvalidAutoscale will be returned when the autoscaler bounds are invalid, or the pool is unlimited or pre-allocated.
+	ErrInvalidAutoscale = errors.New("invalid autoscale options for pool")
+
+	// ErrTaskPanicked will be returned by futures and groups when a task panics.
+	ErrTaskPanicked = errors.New("task panicked")
+
 	// workerChanCap determines whether the channel of a worker should be a buffered channel
 	// to get the best performance. Inspired by fasthttp at
 	// https://github.com/valyala/fasthttp/blob/master/workerpool.go#L139
@@ -81,6 +93,11 @@ func Submit(task func()) error {
 	return defaultAntsPool.Submit(task)
 }
 
+// SubmitWithPriority submits a task to pool with a priority.
+func SubmitWithPriority(task func(), priority int) error {
+	return defaultAntsPool.SubmitWithPriority(task, priority)
+}
+
 // Running returns the number of the currently running goroutines.
 func Running() int {
 	return defaultAntsPool.Running()
@@ -25,8 +25,8 @@ type PoolWithFunc struct {
 	// state is used to notice the pool to closed itself.
 	state int32
 
-	// cond for waiting to get an idle worker.
-	cond *sync.Cond
+	// waiters orders the invokers blocked while waiting to get an idle worker, protected by pool.lock
+	waiters *waitQueue
 
 	// poolFunc is the function for processing tasks.
 	poolFunc func(interface{})
@@ -43,8 +43,13 @@ type PoolWithFunc struct {
 	ticktockDone int32
 	stopTicktock context.CancelFunc
 
+	autoscaleDone int32
+	stopAutoscale context.CancelFunc
+
 	now atomic.Value
 
+	metrics *poolMetrics
+
 	options *Options
 }
 
@@ -82,11 +87,14 @@ func (p *PoolWithFunc) purgeStaleWorkers(ctx context.Context) {
 			staleWorkers[i].finish()
 			staleWorkers[i] = nil
 		}
+		p.metrics.workersExpired(len(staleWorkers))
 
 		// There might be a situation where all workers have been cleaned up(no worker is running),
 		// while some invokers still are stuck in "p.cond.Wait()", then we need to awake those invokers.
 		if isDormant && p.Waiting() > 0 {
-			p.cond.Broadcast()
+			p.lock.Lock()
+			p.waiters.notifyAll()
+			p.lock.Unlock()
 		}
 	}
 }
@@ -125,6 +133,17 @@ func (p *PoolWithFunc) goPurge() {
 	go p.purgeStaleWorkers(ctx)
 }
 
+func (p *PoolWithFunc) goAutoscale() {
+	if p.options.Autoscale == nil {
+		return
+	}
+
+	// Start a goroutine to adjust the capacity periodically.
+	var ctx context.Context
+	ctx, p.stopAutoscale = context.WithCancel(context.Background())
+	go autoscale(ctx, p, p.options.Autoscale, &p.autoscaleDone)
+}
+
 func (p *PoolWithFunc) goTicktock() {
 	p.now.Store(time.Now())
 	var ctx context.Context
@@ -164,6 +183,7 @@ func NewPoolWithFunc(size int, pf func(interface{}), options ...Option) (*PoolWi
 		capacity: int32(size),
 		poolFunc: pf,
 		lock:     NewSpinLock(),
+		metrics:  newPoolMetrics(opts.MetricsHook),
 		options:  opts,
 	}
 	p.workerCache.New = func() interface{} {
@@ -181,10 +201,23 @@ func NewPoolWithFunc(size int, pf func(interface{}), options ...Option) (*PoolWi
 		p.workers = newWorkerQueue(queueTypeStack, 0)
 	}
 
-	p.cond = sync.NewCond(p.lock)
+	if opts.PriorityAging == 0 {
+		opts.PriorityAging = DefaultPriorityAging
+	}
+	p.waiters = newWaitQueue(opts.PriorityAging)
+
+	if opts.Autoscale != nil {
+		autoscale := *opts.Autoscale
+		if err := autoscale.validate(size, opts.PreAlloc); err != nil {
+			return nil, err
+		}
+		opts.Autoscale = &autoscale
+		p.capacity = int32(autoscale.clamp(size))
+	}
 
 	p.goPurge()
 	p.goTicktock()
+	p.goAutoscale()
 
 	return p, nil
 }
@@ -198,13 +231,36 @@ func NewPoolWithFunc(size int, pf func(interface{}), options ...Option) (*PoolWi
 // Pool.Invoke() call once the current Pool runs out of its capacity, and to avoid this,
 // you should instantiate a PoolWithFunc with ants.WithNonblocking(true).
 func (p *PoolWithFunc) Invoke(args interface{}) error {
+	return p.InvokeWithPriority(args, DefaultPriority)
+}
+
+// InvokeWithPriority submits a task to pool with a priority.
+//
+// When the pool runs out of its capacity, blocked invokers are served by priority, the highest first,
+// and in arrival order for the same priority. A blocked invoker gains one priority level every
+// Options.PriorityAging, so that tasks with a low priority are not starved.
+func (p *PoolWithFunc) InvokeWithPriority(args interface{}, priority int) error {
+	return p.invoke(context.Background(), args, priority)
+}
+
+// invoke submits a task to pool, it stops waiting for an available worker when ctx is done.
+func (p *PoolWithFunc) invoke(ctx context.Context, args interface{}, priority int) error {
 	if p.IsClosed() {
 		return ErrPoolClosed
 	}
-	if w := p.retrieveWorker(); w != nil {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	start := time.Now()
+	if w := p.retrieveWorker(ctx, priority); w != nil {
+		p.metrics.taskSubmitted(time.Since(start))
 		w.inputParam(args)
 		return nil
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.metrics.taskRejected()
 	return ErrPoolOverload
 }
 
@@ -232,6 +288,16 @@ func (p *PoolWithFunc) Cap() int {
 	return int(atomic.LoadInt32(&p.capacity))
 }
 
+// Stats returns a snapshot of the pool state and of its cumulative counters.
+func (p *PoolWithFunc) Stats() Stats {
+	return p.metrics.stats(Stats{
+		Cap:     p.Cap(),
+		Running: p.Running(),
+		Free:    p.Free(),
+		Waiting: p.Waiting(),
+	})
+}
+
 // Tune changes the capacity of this pool, note that it is noneffective to the infinite or pre-allocation pool.
 func (p *PoolWithFunc) Tune(size int) {
 	capacity := p.Cap()
@@ -240,11 +306,10 @@ func (p *PoolWithFunc) Tune(size int) {
 	}
 	atomic.StoreInt32(&p.capacity, int32(size))
 	if size > capacity {
-		if size-capacity == 1 {
-			p.cond.Signal()
-			return
+		p.lock.Lock()
+		for i := 0; i < size-capacity && p.waiters.notifyOne(); i++ {
 		}
-		p.cond.Broadcast()
+		p.lock.Unlock()
 	}
 }
 
@@ -265,13 +330,17 @@ func (p *PoolWithFunc) Release() {
 	}
 	p.stopTicktock()
 	p.stopTicktock = nil
+	if p.stopAutoscale != nil {
+		p.stopAutoscale()
+		p.stopAutoscale = nil
+	}
 
 	p.lock.Lock()
 	p.workers.reset()
-	p.lock.Unlock()
 	// There might be some callers waiting in retrieveWorker(), so we need to wake them up to prevent
 	// those callers blocking infinitely.
-	p.cond.Broadcast()
+	p.waiters.notifyAll()
+	p.lock.Unlock()
 }
 
 // ReleaseTimeout is like Release but with a timeout, it waits all workers to exit before timing out.
@@ -285,7 +354,8 @@ func (p *PoolWithFunc) ReleaseTimeout(timeout time.Duration) error {
 	for time.Now().Before(endTime) {
 		if p.Running() == 0 &&
 			(p.options.DisablePurge || atomic.LoadInt32(&p.purgeDone) == 1) &&
-			atomic.LoadInt32(&p.ticktockDone) == 1 {
+			atomic.LoadInt32(&p.ticktockDone) == 1 &&
+			(p.options.Autoscale == nil || atomic.LoadInt32(&p.autoscaleDone) == 1) {
 			return nil
 		}
 		time.Sleep(10 * time.Millisecond)
@@ -300,6 +370,8 @@ func (p *PoolWithFunc) Reboot() {
 		p.goPurge()
 		atomic.StoreInt32(&p.ticktockDone, 0)
 		p.goTicktock()
+		atomic.StoreInt32(&p.autoscaleDone, 0)
+		p.goAutoscale()
 	}
 }
 
@@ -314,58 +386,97 @@ func (p *PoolWithFunc) addWaiting(delta int) {
 }
 
 // retrieveWorker returns an available worker to run the tasks.
-func (p *PoolWithFunc) retrieveWorker() (w worker) {
+// Blocked invokers are served by priority, the highest first, and stop waiting when ctx is done.
+func (p *PoolWithFunc) retrieveWorker(ctx context.Context, priority int) (w worker) {
 	spawnWorker := func() {
 		w = p.workerCache.Get().(*goWorkerWithFunc)
 		w.run()
 	}
 
 	p.lock.Lock()
-	w = p.workers.detach()
-	if w != nil { // first try to fetch the worker from the queue
+	// Invokers do not jump the queue: when some invokers are already blocked, the new one waits in line.
+	if p.waiters.len() == 0 {
+		w = p.workers.detach()
+		if w != nil { // first try to fetch the worker from the queue
+			p.lock.Unlock()
+			return
+		} else if capacity := p.Cap(); capacity == -1 || capacity > p.Running() {
+			// if the worker queue is empty and we don't run out of the pool capacity,
+			// then just spawn a new worker goroutine.
+			p.lock.Unlock()
+			spawnWorker()
+			return
+		}
+	}
+
+	// otherwise, we'll have to keep them blocked and wait for at least one worker to be put back into pool.
+	if p.options.Nonblocking {
 		p.lock.Unlock()
-	} else if capacity := p.Cap(); capacity == -1 || capacity > p.Running() {
-		// if the worker queue is empty and we don't run out of the pool capacity,
-		// then just spawn a new worker goroutine.
+		return
+	}
+	if p.options.MaxBlockingTasks != 0 && p.Waiting() >= p.options.MaxBlockingTasks {
 		p.lock.Unlock()
-		spawnWorker()
-	} else { // otherwise, we'll have to keep them blocked and wait for at least one worker to be put back into pool.
-		if p.options.Nonblocking {
+		return
+	}
+
+	wt := p.waiters.newWaiter(priority)
+	p.addWaiting(1)
+	defer p.addWaiting(-1)
+
+	for {
+		p.waiters.push(wt)
+		p.lock.Unlock()
+		select {
+		case <-wt.ready: // block and wait for an available worker
+			p.lock.Lock()
+		case <-ctx.Done():
+			p.lock.Lock()
+			if wt.index >= 0 {
+				p.waiters.remove(wt)
+			} else {
+				// the waiter has been notified in the meantime, pass the notification on.
+				p.waiters.notifyOne()
+			}
 			p.lock.Unlock()
 			return
 		}
-	retry:
-		if p.options.MaxBlockingTasks != 0 && p.Waiting() >= p.options.MaxBlockingTasks {
+
+		if p.IsClosed() {
 			p.lock.Unlock()
 			return
 		}
 
-		p.addWaiting(1)
-		p.cond.Wait() // block and wait for an available worker
-		p.addWaiting(-1)
-
-		if p.IsClosed() {
+		if w = p.workers.detach(); w != nil {
+			// pass the baton when more workers are available
+			if !p.workers.isEmpty() {
+				p.waiters.notifyOne()
+			}
 			p.lock.Unlock()
 			return
 		}
 
-		if w = p.workers.detach(); w == nil {
-			if p.Free() > 0 {
-				p.lock.Unlock()
-				spawnWorker()
-				return
+		if free := p.Free(); free > 0 {
+			if free > 1 {
+				p.waiters.notifyOne()
 			}
-			goto retry
+			p.lock.Unlock()
+			spawnWorker()
+			return
 		}
-		p.lock.Unlock()
 	}
-	return
+}
+
+// wakeWaiter notifies the first invoker stuck in 'retrieveWorker()' that a worker might be available.
+func (p *PoolWithFunc) wakeWaiter() {
+	p.lock.Lock()
+	p.waiters.notifyOne()
+	p.lock.Unlock()
 }
 
 // revertWorker puts a worker back into free pool, recycling the goroutines.
 func (p *PoolWithFunc) revertWorker(worker *goWorkerWithFunc) bool {
+	// The worker exits, and notifies the invokers stuck in 'retrieveWorker()' on its way out.
 	if capacity := p.Cap(); (capacity > 0 && p.Running() > capacity) || p.IsClosed() {
-		p.cond.Broadcast()
 		return false
 	}
 
@@ -382,7 +493,7 @@ func (p *PoolWithFunc) revertWorker(worker *goWorkerWithFunc) bool {
 		return false
 	}
 	// Notify the invoker stuck in 'retrieveWorker()' of there is an available worker in the worker queue.
-	p.cond.Signal()
+	p.waiters.notifyOne()
 	p.lock.Unlock()
 
 	return true
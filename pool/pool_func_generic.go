@@ -0,0 +1,158 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// funcPool exposes the management methods of the PoolWithFunc backing a generic pool.
+type funcPool struct {
+	pool *PoolWithFunc
+}
+
+// Running returns the number of workers currently running.
+func (p funcPool) Running() int {
+	return p.pool.Running()
+}
+
+// Free returns the number of available goroutines to work, -1 indicates this pool is unlimited.
+func (p funcPool) Free() int {
+	return p.pool.Free()
+}
+
+// Waiting returns the number of tasks which are waiting be executed.
+func (p funcPool) Waiting() int {
+	return p.pool.Waiting()
+}
+
+// Cap returns the capacity of this pool.
+func (p funcPool) Cap() int {
+	return p.pool.Cap()
+}
+
+// Stats returns a snapshot of the pool state and of its cumulative counters.
+func (p funcPool) Stats() Stats {
+	return p.pool.Stats()
+}
+
+// Tune changes the capacity of this pool, note that it is noneffective to the infinite or pre-allocation pool.
+func (p funcPool) Tune(size int) {
+	p.pool.Tune(size)
+}
+
+// IsClosed indicates whether the pool is closed.
+func (p funcPool) IsClosed() bool {
+	return p.pool.IsClosed()
+}
+
+// Release closes this pool and releases the worker queue.
+func (p funcPool) Release() {
+	p.pool.Release()
+}
+
+// ReleaseTimeout is like Release but with a timeout, it waits all workers to exit before timing out.
+func (p funcPool) ReleaseTimeout(timeout time.Duration) error {
+	return p.pool.ReleaseTimeout(timeout)
+}
+
+// Reboot reboots a closed pool.
+func (p funcPool) Reboot() {
+	p.pool.Reboot()
+}
+
+// genericArg boxes the arguments of a generic pool, since a nil argument stops a worker of PoolWithFunc.
+type genericArg[T any] struct {
+	value T
+}
+
+// PoolWithFuncGeneric is a PoolWithFunc whose function takes a typed argument,
+// so that the invokers don't need type assertions.
+type PoolWithFuncGeneric[T any] struct {
+	funcPool
+}
+
+// NewPoolWithFuncGeneric generates an instance of ants pool with a specific typed function.
+// It accepts the same options as NewPoolWithFunc.
+func NewPoolWithFuncGeneric[T any](size int, pf func(T), options ...Option) (*PoolWithFuncGeneric[T], error) {
+	if pf == nil {
+		return nil, ErrLackPoolFunc
+	}
+
+	p, err := NewPoolWithFunc(size, func(args interface{}) {
+		pf(args.(genericArg[T]).value)
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PoolWithFuncGeneric[T]{funcPool{pool: p}}, nil
+}
+
+// Invoke submits a task to pool.
+func (p *PoolWithFuncGeneric[T]) Invoke(args T) error {
+	return p.pool.invoke(context.Background(), genericArg[T]{value: args}, DefaultPriority)
+}
+
+// InvokeWithPriority submits a task to pool with a priority.
+func (p *PoolWithFuncGeneric[T]) InvokeWithPriority(args T, priority int) error {
+	return p.pool.invoke(context.Background(), genericArg[T]{value: args}, priority)
+}
+
+// InvokeCtx submits a task to pool, it stops waiting for an available worker when ctx is done, and returns ctx.Err().
+func (p *PoolWithFuncGeneric[T]) InvokeCtx(ctx context.Context, args T) error {
+	return p.pool.invoke(ctx, genericArg[T]{value: args}, DefaultPriority)
+}
+
+// typedCall is a task of a TypedPool.
+type typedCall[T, R any] struct {
+	ctx    context.Context
+	args   T
+	future *Future[R]
+}
+
+// TypedPool is a PoolWithFunc whose function takes a typed argument and returns a typed result,
+// the result of each task is delivered through a Future.
+type TypedPool[T, R any] struct {
+	funcPool
+}
+
+// NewTypedPool generates an instance of ants pool with a specific function returning a result.
+// It accepts the same options as NewPoolWithFunc.
+//
+// If the function panics, the future of the task is rejected with ErrTaskPanicked,
+// and the panic is handled by the pool as usual.
+func NewTypedPool[T, R any](size int, pf func(ctx context.Context, args T) (R, error), options ...Option) (*TypedPool[T, R], error) {
+	if pf == nil {
+		return nil, ErrLackPoolFunc
+	}
+
+	p, err := NewPoolWithFunc(size, func(args interface{}) {
+		call := args.(*typedCall[T, R])
+		_ = call.future.run(call.ctx, func(ctx context.Context) (R, error) {
+			return pf(ctx, call.args)
+		})
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedPool[T, R]{funcPool{pool: p}}, nil
+}
+
+// Invoke submits a task to pool, and returns a future of its result.
+//
+// If the task cannot be submitted, or if ctx is done before the task starts, the future
+// is rejected with the corresponding error.
+func (p *TypedPool[T, R]) Invoke(ctx context.Context, args T) *Future[R] {
+	return p.InvokeWithPriority(ctx, args, DefaultPriority)
+}
+
+// InvokeWithPriority submits a task to pool with a priority, and returns a future of its result.
+func (p *TypedPool[T, R]) InvokeWithPriority(ctx context.Context, args T, priority int) *Future[R] {
+	f := newFuture[R]()
+	call := &typedCall[T, R]{ctx: ctx, args: args, future: f}
+	if err := p.pool.invoke(ctx, call, priority); err != nil {
+		f.complete(f.value, err)
+	}
+	return f
+}
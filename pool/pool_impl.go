@@ -26,8 +26,8 @@ type Pool struct {
 	// state is used to notice the pool to closed itself.
 	state int32
 
-	// cond for waiting to get an idle worker.
-	cond *sync.Cond
+	// waiters orders the invokers blocked while waiting to get an idle worker, protected by pool.lock
+	waiters *waitQueue
 
 	// workerCache speeds up the obtainment of a usable worker in function:retrieveWorker.
 	workerCache sync.Pool
@@ -41,8 +41,13 @@ type Pool struct {
 	ticktockDone int32
 	stopTicktock context.CancelFunc
 
+	autoscaleDone int32
+	stopAutoscale context.CancelFunc
+
 	now atomic.Value
 
+	metrics *poolMetrics
+
 	options *Options
 }
 
@@ -81,11 +86,14 @@ func (p *Pool) purgeStaleWorkers(ctx context.Context) {
 			staleWorkers[i].finish()
 			staleWorkers[i] = nil
 		}
+		p.metrics.workersExpired(len(staleWorkers))
 
 		// There might be a situation where all workers have been cleaned up(no worker is running),
 		// while some invokers still are stuck in "p.cond.Wait()", then we need to awake those invokers.
 		if isDormant && p.Waiting() > 0 {
-			p.cond.Broadcast()
+			p.lock.Lock()
+			p.waiters.notifyAll()
+			p.lock.Unlock()
 		}
 	}
 }
@@ -124,6 +132,17 @@ func (p *Pool) goPurge() {
 	go p.purgeStaleWorkers(ctx)
 }
 
+func (p *Pool) goAutoscale() {
+	if p.options.Autoscale == nil {
+		return
+	}
+
+	// Start a goroutine to adjust the capacity periodically.
+	var ctx context.Context
+	ctx, p.stopAutoscale = context.WithCancel(context.Background())
+	go autoscale(ctx, p, p.options.Autoscale, &p.autoscaleDone)
+}
+
 func (p *Pool) goTicktock() {
 	p.now.Store(time.Now())
 	var ctx context.Context
@@ -158,6 +177,7 @@ func NewPool(size int, options ...Option) (*Pool, error) {
 	p := &Pool{
 		capacity: int32(size),
 		lock:     NewSpinLock(),
+		metrics:  newPoolMetrics(opts.MetricsHook),
 		options:  opts,
 	}
 	p.workerCache.New = func() interface{} {
@@ -175,10 +195,23 @@ func NewPool(size int, options ...Option) (*Pool, error) {
 		p.workers = newWorkerQueue(queueTypeStack, 0)
 	}
 
-	p.cond = sync.NewCond(p.lock)
+	if opts.PriorityAging == 0 {
+		opts.PriorityAging = DefaultPriorityAging
+	}
+	p.waiters = newWaitQueue(opts.PriorityAging)
+
+	if opts.Autoscale != nil {
+		autoscale := *opts.Autoscale
+		if err := autoscale.validate(size, opts.PreAlloc); err != nil {
+			return nil, err
+		}
+		opts.Autoscale = &autoscale
+		p.capacity = int32(autoscale.clamp(size))
+	}
 
 	p.goPurge()
 	p.goTicktock()
+	p.goAutoscale()
 
 	return p, nil
 }
@@ -192,13 +225,51 @@ func NewPool(size int, options ...Option) (*Pool, error) {
 // Pool.Submit() call once the current Pool runs out of its capacity, and to avoid this,
 // you should instantiate a Pool with ants.WithNonblocking(true).
 func (p *Pool) Submit(task func()) error {
+	return p.SubmitWithPriority(task, DefaultPriority)
+}
+
+// SubmitWithPriority submits a task to this pool with a priority.
+//
+// When the pool runs out of its capacity, blocked invokers are served by priority, the highest first,
+// and in arrival order for the same priority. A blocked invoker gains one priority level every
+// Options.PriorityAging, so that tasks with a low priority are not starved.
+func (p *Pool) SubmitWithPriority(task func(), priority int) error {
 	if p.IsClosed() {
 		return ErrPoolClosed
 	}
-	if w := p.retrieveWorker(); w != nil {
+	start := time.Now()
+	if w := p.retrieveWorker(context.Background(), priority); w != nil {
+		p.metrics.taskSubmitted(time.Since(start))
 		w.inputFunc(task)
 		return nil
 	}
+	p.metrics.taskRejected()
+	return ErrPoolOverload
+}
+
+// SubmitCtx submits a task to this pool, the task receives ctx when it runs.
+//
+// SubmitCtx stops waiting for an available worker when ctx is done, and returns ctx.Err().
+// The error returned by the task is discarded, use Go or a Group to collect it.
+func (p *Pool) SubmitCtx(ctx context.Context, task func(ctx context.Context) error) error {
+	if p.IsClosed() {
+		return ErrPoolClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	start := time.Now()
+	if w := p.retrieveWorker(ctx, DefaultPriority); w != nil {
+		p.metrics.taskSubmitted(time.Since(start))
+		w.inputFunc(func() {
+			_ = task(ctx)
+		})
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.metrics.taskRejected()
 	return ErrPoolOverload
 }
 
@@ -226,6 +297,16 @@ func (p *Pool) Cap() int {
 	return int(atomic.LoadInt32(&p.capacity))
 }
 
+// Stats returns a snapshot of the pool state and of its cumulative counters.
+func (p *Pool) Stats() Stats {
+	return p.metrics.stats(Stats{
+		Cap:     p.Cap(),
+		Running: p.Running(),
+		Free:    p.Free(),
+		Waiting: p.Waiting(),
+	})
+}
+
 // Tune changes the capacity of this pool, note that it is noneffective to the infinite or pre-allocation pool.
 func (p *Pool) Tune(size int) {
 	capacity := p.Cap()
@@ -234,11 +315,10 @@ func (p *Pool) Tune(size int) {
 	}
 	atomic.StoreInt32(&p.capacity, int32(size))
 	if size > capacity {
-		if size-capacity == 1 {
-			p.cond.Signal()
-			return
+		p.lock.Lock()
+		for i := 0; i < size-capacity && p.waiters.notifyOne(); i++ {
 		}
-		p.cond.Broadcast()
+		p.lock.Unlock()
 	}
 }
 
@@ -259,13 +339,17 @@ func (p *Pool) Release() {
 	}
 	p.stopTicktock()
 	p.stopTicktock = nil
+	if p.stopAutoscale != nil {
+		p.stopAutoscale()
+		p.stopAutoscale = nil
+	}
 
 	p.lock.Lock()
 	p.workers.reset()
-	p.lock.Unlock()
 	// There might be some callers waiting in retrieveWorker(), so we need to wake them up to prevent
 	// those callers blocking infinitely.
-	p.cond.Broadcast()
+	p.waiters.notifyAll()
+	p.lock.Unlock()
 }
 
 // ReleaseTimeout is like Release but with a timeout, it waits all workers to exit before timing out.
@@ -279,7 +363,8 @@ func (p *Pool) ReleaseTimeout(timeout time.Duration) error {
 	for time.Now().Before(endTime) {
 		if p.Running() == 0 &&
 			(p.options.DisablePurge || atomic.LoadInt32(&p.purgeDone) == 1) &&
-			atomic.LoadInt32(&p.ticktockDone) == 1 {
+			atomic.LoadInt32(&p.ticktockDone) == 1 &&
+			(p.options.Autoscale == nil || atomic.LoadInt32(&p.autoscaleDone) == 1) {
 			return nil
 		}
 		time.Sleep(10 * time.Millisecond)
@@ -294,6 +379,8 @@ func (p *Pool) Reboot() {
 		p.goPurge()
 		atomic.StoreInt32(&p.ticktockDone, 0)
 		p.goTicktock()
+		atomic.StoreInt32(&p.autoscaleDone, 0)
+		p.goAutoscale()
 	}
 }
 
@@ -308,58 +395,97 @@ func (p *Pool) addWaiting(delta int) {
 }
 
 // retrieveWorker returns an available worker to run the tasks.
-func (p *Pool) retrieveWorker() (w worker) {
+// Blocked invokers are served by priority, the highest first, and stop waiting when ctx is done.
+func (p *Pool) retrieveWorker(ctx context.Context, priority int) (w worker) {
 	spawnWorker := func() {
 		w = p.workerCache.Get().(*goWorker)
 		w.run()
 	}
 
 	p.lock.Lock()
-	w = p.workers.detach()
-	if w != nil { // first try to fetch the worker from the queue
+	// Invokers do not jump the queue: when some invokers are already blocked, the new one waits in line.
+	if p.waiters.len() == 0 {
+		w = p.workers.detach()
+		if w != nil { // first try to fetch the worker from the queue
+			p.lock.Unlock()
+			return
+		} else if capacity := p.Cap(); capacity == -1 || capacity > p.Running() {
+			// if the worker queue is empty and we don't run out of the pool capacity,
+			// then just spawn a new worker goroutine.
+			p.lock.Unlock()
+			spawnWorker()
+			return
+		}
+	}
+
+	// otherwise, we'll have to keep them blocked and wait for at least one worker to be put back into pool.
+	if p.options.Nonblocking {
+		p.lock.Unlock()
+		return
+	}
+	if p.options.MaxBlockingTasks != 0 && p.Waiting() >= p.options.MaxBlockingTasks {
 		p.lock.Unlock()
-	} else if capacity := p.Cap(); capacity == -1 || capacity > p.Running() {
-		// if the worker queue is empty and we don't run out of the pool capacity,
-		// then just spawn a new worker goroutine.
+		return
+	}
+
+	wt := p.waiters.newWaiter(priority)
+	p.addWaiting(1)
+	defer p.addWaiting(-1)
+
+	for {
+		p.waiters.push(wt)
 		p.lock.Unlock()
-		spawnWorker()
-	} else { // otherwise, we'll have to keep them blocked and wait for at least one worker to be put back into pool.
-		if p.options.Nonblocking {
+		select {
+		case <-wt.ready: // block and wait for an available worker
+			p.lock.Lock()
+		case <-ctx.Done():
+			p.lock.Lock()
+			if wt.index >= 0 {
+				p.waiters.remove(wt)
+			} else {
+				// the waiter has been notified in the meantime, pass the notification on.
+				p.waiters.notifyOne()
+			}
 			p.lock.Unlock()
 			return
 		}
-	retry:
-		if p.options.MaxBlockingTasks != 0 && p.Waiting() >= p.options.MaxBlockingTasks {
+
+		if p.IsClosed() {
 			p.lock.Unlock()
 			return
 		}
 
-		p.addWaiting(1)
-		p.cond.Wait() // block and wait for an available worker
-		p.addWaiting(-1)
-
-		if p.IsClosed() {
+		if w = p.workers.detach(); w != nil {
+			// pass the baton when more workers are available
+			if !p.workers.isEmpty() {
+				p.waiters.notifyOne()
+			}
 			p.lock.Unlock()
 			return
 		}
 
-		if w = p.workers.detach(); w == nil {
-			if p.Free() > 0 {
-				p.lock.Unlock()
-				spawnWorker()
-				return
+		if free := p.Free(); free > 0 {
+			if free > 1 {
+				p.waiters.notifyOne()
 			}
-			goto retry
+			p.lock.Unlock()
+			spawnWorker()
+			return
 		}
-		p.lock.Unlock()
 	}
-	return
+}
+
+// wakeWaiter notifies the first invoker stuck in 'retrieveWorker()' that a worker might be available.
+func (p *Pool) wakeWaiter() {
+	p.lock.Lock()
+	p.waiters.notifyOne()
+	p.lock.Unlock()
 }
 
 // revertWorker puts a worker back into free pool, recycling the goroutines.
 func (p *Pool) revertWorker(worker *goWorker) bool {
+	// The worker exits, and notifies the invokers stuck in 'retrieveWorker()' on its way out.
 	if capacity := p.Cap(); (capacity > 0 && p.Running() > capacity) || p.IsClosed() {
-		p.cond.Broadcast()
 		return false
 	}
 
@@ -376,7 +502,7 @@ func (p *Pool) revertWorker(worker *goWorker) bool {
 		return false
 	}
 	// Notify the invoker stuck in 'retrieveWorker()' of there is an available worker in the worker queue.
-	p.cond.Signal()
+	p.waiters.notifyOne()
 	p.lock.Unlock()
 
 	return true
@@ -1,7 +1,10 @@
 package pool
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"math"
 	"os"
 	"runtime"
 	"sync"
@@ -963,3 +966,877 @@ func TestDefaultPoolReleaseTimeout(t *testing.T) {
 	err := ReleaseTimeout(2 * time.Second)
 	assert.NoError(t, err)
 }
+
+// submitBlocked submits a task with a priority from a new goroutine, and waits until it is blocked in the pool.
+func submitBlocked(t *testing.T, p *Pool, priority int, task func()) {
+	waiting := p.Waiting()
+	go func() {
+		assert.NoError(t, p.SubmitWithPriority(task, priority))
+	}()
+	for p.Waiting() == waiting {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubmitWithPriority(t *testing.T) {
+	p, _ := NewPool(1, WithPriorityAging(-1))
+	defer p.Release()
+
+	block := make(chan struct{})
+	_ = p.Submit(func() { <-block })
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for _, priority := range []int{1, 5, 3, 5, -2} {
+		priority := priority
+		wg.Add(1)
+		submitBlocked(t, p, priority, func() {
+			mu.Lock()
+			order = append(order, priority)
+			mu.Unlock()
+			wg.Done()
+		})
+	}
+	assert.EqualValues(t, 5, p.Waiting())
+
+	close(block)
+	wg.Wait()
+	assert.EqualValues(t, []int{5, 5, 3, 1, -2}, order)
+	assert.EqualValues(t, 0, p.Waiting())
+}
+
+func TestSubmitWithPriorityAging(t *testing.T) {
+	p, _ := NewPool(1, WithPriorityAging(10*time.Millisecond))
+	defer p.Release()
+
+	block := make(chan struct{})
+	_ = p.Submit(func() { <-block })
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	record := func(name string) func() {
+		wg.Add(1)
+		return func() {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			wg.Done()
+		}
+	}
+
+	submitBlocked(t, p, 0, record("old-low"))
+	time.Sleep(100 * time.Millisecond)
+	submitBlocked(t, p, 2, record("new-high"))
+	submitBlocked(t, p, 20, record("new-urgent"))
+
+	close(block)
+	wg.Wait()
+	// the old task waited for about 10 priority levels
+	assert.EqualValues(t, []string{"new-urgent", "old-low", "new-high"}, order)
+}
+
+func TestSubmitWithPriorityAgingOverflow(t *testing.T) {
+	p, _ := NewPool(1, WithPriorityAging(time.Second))
+	defer p.Release()
+
+	block := make(chan struct{})
+	_ = p.Submit(func() { <-block })
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	record := func(name string) func() {
+		wg.Add(1)
+		return func() {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			wg.Done()
+		}
+	}
+
+	submitBlocked(t, p, math.MinInt, record("min"))
+	submitBlocked(t, p, 0, record("zero"))
+	submitBlocked(t, p, math.MaxInt, record("max"))
+	submitBlocked(t, p, math.MaxInt/2, record("half"))
+
+	close(block)
+	wg.Wait()
+	// the priorities saturate instead of wrapping around
+	assert.EqualValues(t, []string{"max", "half", "zero", "min"}, order)
+
+	now := time.Now().UnixNano()
+	assert.EqualValues(t, int64(math.MinInt64), agingKey(now, math.MaxInt, time.Second))
+	assert.EqualValues(t, int64(math.MaxInt64), agingKey(now, math.MinInt, time.Second))
+	assert.EqualValues(t, int64(math.MaxInt64), agingKey(math.MaxInt64-1, -2, 1))
+	assert.EqualValues(t, now-2*int64(time.Second), agingKey(now, 2, time.Second))
+}
+
+func TestSubmitWithPriorityMaxBlockingTasks(t *testing.T) {
+	p, _ := NewPool(1, WithMaxBlockingTasks(1))
+	defer p.Release()
+
+	block := make(chan struct{})
+	_ = p.Submit(func() { <-block })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	submitBlocked(t, p, 0, wg.Done)
+	assert.ErrorIs(t, p.SubmitWithPriority(demoFunc, 10), ErrPoolOverload)
+
+	close(block)
+	wg.Wait()
+}
+
+func TestSubmitWithPriorityNonblocking(t *testing.T) {
+	p, _ := NewPool(1, WithNonblocking(true))
+	defer p.Release()
+
+	block := make(chan struct{})
+	_ = p.Submit(func() { <-block })
+	assert.ErrorIs(t, p.SubmitWithPriority(demoFunc, 10), ErrPoolOverload)
+	close(block)
+}
+
+func TestSubmitWithPriorityTune(t *testing.T) {
+	p, _ := NewPool(1)
+	defer p.Release()
+
+	block := make(chan struct{})
+	_ = p.Submit(func() { <-block })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		submitBlocked(t, p, i, func() {
+			wg.Done()
+			<-block
+		})
+	}
+
+	p.Tune(4)
+	wg.Wait()
+	assert.EqualValues(t, 4, p.Running())
+	assert.EqualValues(t, 0, p.Waiting())
+	close(block)
+}
+
+func TestSubmitWithPriorityRelease(t *testing.T) {
+	p, _ := NewPool(1)
+
+	block := make(chan struct{})
+	defer close(block)
+	_ = p.Submit(func() { <-block })
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- p.SubmitWithPriority(demoFunc, 1)
+	}()
+	for p.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	p.Release()
+	assert.ErrorIs(t, <-errs, ErrPoolOverload)
+}
+
+func TestInvokeWithPriority(t *testing.T) {
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	block := make(chan struct{})
+
+	p, _ := NewPoolWithFunc(1, func(i interface{}) {
+		if i == nil {
+			return
+		}
+		if i.(int) < 0 {
+			<-block
+			return
+		}
+		mu.Lock()
+		order = append(order, i.(int))
+		mu.Unlock()
+		wg.Done()
+	}, WithPriorityAging(-1))
+	defer p.Release()
+
+	_ = p.Invoke(-1)
+	for _, priority := range []int{1, 3, 2} {
+		priority := priority
+		wg.Add(1)
+		waiting := p.Waiting()
+		go func() {
+			assert.NoError(t, p.InvokeWithPriority(priority, priority))
+		}()
+		for p.Waiting() == waiting {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	close(block)
+	wg.Wait()
+	assert.EqualValues(t, []int{3, 2, 1}, order)
+}
+
+func TestSubmitCtx(t *testing.T) {
+	p, _ := NewPool(1)
+	defer p.Release()
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	done := make(chan interface{}, 1)
+	assert.NoError(t, p.SubmitCtx(ctx, func(ctx context.Context) error {
+		done <- ctx.Value(key{})
+		return nil
+	}))
+	assert.EqualValues(t, "value", <-done)
+
+	// stop waiting for a worker
+	block := make(chan struct{})
+	defer close(block)
+	_ = p.Submit(func() { <-block })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := p.SubmitCtx(ctx, func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualValues(t, 0, p.Waiting())
+
+	// already canceled
+	assert.ErrorIs(t, p.SubmitCtx(ctx, func(ctx context.Context) error { return nil }), context.DeadlineExceeded)
+
+	p.Release()
+	assert.ErrorIs(t, p.SubmitCtx(context.Background(), func(ctx context.Context) error { return nil }), ErrPoolClosed)
+}
+
+func TestSubmitCtxCancelNotified(t *testing.T) {
+	p, _ := NewPool(1)
+	defer p.Release()
+
+	// a waiter leaving the queue must not swallow the notification of an available worker.
+	for i := 0; i < 100; i++ {
+		block := make(chan struct{})
+		_ = p.Submit(func() { <-block })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error, 1)
+		go func() {
+			errs <- p.SubmitCtx(ctx, func(ctx context.Context) error { return nil })
+		}()
+		for p.Waiting() == 0 {
+			time.Sleep(time.Microsecond)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			_ = p.Submit(func() {})
+			close(done)
+		}()
+		for p.Waiting() < 2 {
+			time.Sleep(time.Microsecond)
+		}
+
+		go cancel()
+		close(block)
+
+		<-done
+		<-errs
+	}
+}
+
+func TestGo(t *testing.T) {
+	p, _ := NewPool(2)
+	defer p.Release()
+
+	f := Go(p, context.Background(), func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	v, err := f.Await()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 42, v)
+
+	f = Go(p, context.Background(), func(ctx context.Context) (int, error) {
+		return 0, assert.AnError
+	})
+	<-f.Done()
+	_, err = f.Await()
+	assert.ErrorIs(t, err, assert.AnError)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f = Go(p, ctx, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	_, err = f.Await()
+	assert.ErrorIs(t, err, context.Canceled)
+
+	slow := Go(p, context.Background(), func(ctx context.Context) (int, error) {
+		time.Sleep(50 * time.Millisecond)
+		return 1, nil
+	})
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = slow.AwaitCtx(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	v, err = slow.AwaitCtx(context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, v)
+}
+
+func TestGoPanic(t *testing.T) {
+	var panicked int32
+	p, _ := NewPool(1, WithPanicHandler(func(interface{}) {
+		atomic.AddInt32(&panicked, 1)
+	}))
+	defer p.Release()
+
+	f := Go(p, context.Background(), func(ctx context.Context) (int, error) {
+		panic("boom")
+	})
+	_, err := f.Await()
+	assert.ErrorIs(t, err, ErrTaskPanicked)
+	assert.Contains(t, err.Error(), "boom")
+
+	time.Sleep(10 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&panicked))
+}
+
+func TestGroup(t *testing.T) {
+	p, _ := NewPool(2)
+	defer p.Release()
+
+	var running, maxRunning int32
+	g := NewGroup(p)
+	for i := 0; i < 10; i++ {
+		i := i
+		g.Go(func(ctx context.Context) error {
+			current := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+
+			if i%4 == 0 {
+				return fmt.Errorf("task %d: %w", i, assert.AnError)
+			}
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 3)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+
+	assert.NoError(t, NewGroup(p).Wait())
+}
+
+func TestGroupWithContext(t *testing.T) {
+	p, _ := NewPool(1)
+	defer p.Release()
+
+	g, ctx := NewGroupWithContext(context.Background(), p)
+	g.Go(func(ctx context.Context) error {
+		return assert.AnError
+	})
+	<-ctx.Done()
+
+	var ran int32
+	for i := 0; i < 3; i++ {
+		g.Go(func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NotErrorIs(t, err, context.Canceled)
+	assert.EqualValues(t, 0, atomic.LoadInt32(&ran))
+}
+
+type countingHook struct {
+	submitted, rejected, done, panicked, spawned, expired int32
+}
+
+func (h *countingHook) TaskSubmitted(time.Duration) { atomic.AddInt32(&h.submitted, 1) }
+func (h *countingHook) TaskRejected()               { atomic.AddInt32(&h.rejected, 1) }
+func (h *countingHook) WorkerSpawned()              { atomic.AddInt32(&h.spawned, 1) }
+func (h *countingHook) WorkersExpired(n int)        { atomic.AddInt32(&h.expired, int32(n)) }
+func (h *countingHook) TaskDone(_ time.Duration, panicked bool) {
+	atomic.AddInt32(&h.done, 1)
+	if panicked {
+		atomic.AddInt32(&h.panicked, 1)
+	}
+}
+
+func TestStats(t *testing.T) {
+	hook := &countingHook{}
+	p, _ := NewPool(1, WithNonblocking(true), WithMetricsHook(hook), WithPanicHandler(func(interface{}) {}))
+	defer p.Release()
+
+	release := make(chan struct{})
+	assert.NoError(t, p.Submit(func() {
+		time.Sleep(20 * time.Millisecond)
+		<-release
+	}))
+	assert.ErrorIs(t, p.Submit(func() {}), ErrPoolOverload)
+
+	stats := p.Stats()
+	assert.Equal(t, 1, stats.Cap)
+	assert.Equal(t, 1, stats.Running)
+	assert.Equal(t, 0, stats.Free)
+	assert.EqualValues(t, 1, stats.Submitted)
+	assert.EqualValues(t, 1, stats.Rejected)
+	assert.EqualValues(t, 0, stats.Completed)
+	assert.EqualValues(t, 1, stats.WorkersSpawned)
+
+	close(release)
+	assert.Eventually(t, func() bool { return p.Stats().Completed == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, p.Submit(func() { panic("boom") }))
+	assert.Eventually(t, func() bool { return p.Stats().Panicked == 1 }, time.Second, time.Millisecond)
+
+	stats = p.Stats()
+	assert.EqualValues(t, 2, stats.Submitted)
+	assert.EqualValues(t, 1, stats.Completed)
+	assert.EqualValues(t, 2, stats.QueueWait.Count)
+	assert.EqualValues(t, 2, stats.ExecTime.Count)
+	assert.Len(t, stats.ExecTime.Counts, len(DefaultHistogramBounds)+1)
+	assert.GreaterOrEqual(t, stats.ExecTime.Sum, 20*time.Millisecond)
+	assert.Equal(t, stats.ExecTime.Sum/2, stats.ExecTime.Mean())
+
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hook.submitted))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hook.rejected))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hook.done))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hook.panicked))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hook.spawned))
+}
+
+func TestStatsWorkersExpired(t *testing.T) {
+	hook := &countingHook{}
+	p, _ := NewPoolWithFunc(3, func(interface{}) {}, WithExpiryDuration(10*time.Millisecond), WithMetricsHook(hook))
+	defer p.Release()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, p.Invoke(i))
+	}
+
+	assert.Eventually(t, func() bool {
+		stats := p.Stats()
+		return stats.Running == 0 && stats.WorkersExpired == stats.WorkersSpawned
+	}, time.Second, time.Millisecond)
+	stats := p.Stats()
+	assert.EqualValues(t, 3, stats.Submitted)
+	assert.EqualValues(t, 3, stats.Completed)
+	assert.NotZero(t, stats.WorkersExpired)
+	assert.EqualValues(t, stats.WorkersSpawned, stats.WorkersExpired)
+	assert.EqualValues(t, stats.WorkersExpired, atomic.LoadInt32(&hook.expired))
+	assert.Zero(t, Histogram{}.Mean())
+}
+
+func TestAIMDPolicy(t *testing.T) {
+	policy := AIMDPolicy{Increase: 2, MaxQueueWait: time.Millisecond}
+	assert.Equal(t, 6, policy.Next(AutoscaleSample{Cap: 4, Waiting: 1}))
+	assert.Equal(t, 6, policy.Next(AutoscaleSample{Cap: 4, Rejected: 1}))
+	assert.Equal(t, 6, policy.Next(AutoscaleSample{Cap: 4, QueueWait: 2 * time.Millisecond}))
+	assert.Equal(t, 2, policy.Next(AutoscaleSample{Cap: 4, Busy: 1, Utilization: 0.25}))
+	assert.Equal(t, 4, policy.Next(AutoscaleSample{Cap: 4, Busy: 3, Utilization: 0.75}))
+}
+
+func TestTargetLatencyPolicy(t *testing.T) {
+	policy := TargetLatencyPolicy{Target: 10 * time.Millisecond, MaxGrowth: 3}
+	assert.Equal(t, 6, policy.Next(AutoscaleSample{Cap: 4, QueueWait: 15 * time.Millisecond}))
+	assert.Equal(t, 12, policy.Next(AutoscaleSample{Cap: 4, QueueWait: time.Second}))
+	assert.Equal(t, 5, policy.Next(AutoscaleSample{Cap: 4, Waiting: 1}))
+	assert.Equal(t, 4, policy.Next(AutoscaleSample{Cap: 8, Busy: 3, Utilization: 0.375}))
+	assert.Equal(t, 8, policy.Next(AutoscaleSample{Cap: 8, Busy: 8, Utilization: 1}))
+}
+
+func TestAutoscale(t *testing.T) {
+	var scaled int32
+	p, err := NewPool(10, WithExpiryDuration(10*time.Millisecond), WithAutoscale(AutoscaleOptions{
+		Min:               1,
+		Max:               4,
+		Interval:          5 * time.Millisecond,
+		ScaleDownCooldown: 5 * time.Millisecond,
+		OnScale: func(from, to int, sample AutoscaleSample) {
+			atomic.AddInt32(&scaled, 1)
+		},
+	}))
+	assert.NoError(t, err)
+	defer p.Release()
+	assert.Equal(t, 4, p.Cap())
+
+	// underused: the capacity shrinks to the minimum
+	assert.Eventually(t, func() bool { return p.Cap() == 1 }, time.Second, time.Millisecond)
+
+	// congested: the capacity grows to the maximum
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = p.Submit(func() { <-release })
+		}()
+	}
+	assert.Eventually(t, func() bool { return p.Cap() == 4 }, time.Second, time.Millisecond)
+	assert.LessOrEqual(t, p.Running(), 4)
+
+	close(release)
+	wg.Wait()
+	assert.Eventually(t, func() bool { return p.Cap() == 1 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return p.Running() <= 1 }, time.Second, time.Millisecond)
+	assert.NotZero(t, atomic.LoadInt32(&scaled))
+
+	assert.NoError(t, p.ReleaseTimeout(time.Second))
+	p.Reboot()
+	assert.NotNil(t, p.stopAutoscale)
+}
+
+func TestAutoscaleInvalid(t *testing.T) {
+	_, err := NewPool(10, WithAutoscale(AutoscaleOptions{Min: 0, Max: 4}))
+	assert.ErrorIs(t, err, ErrInvalidAutoscale)
+	_, err = NewPool(10, WithAutoscale(AutoscaleOptions{Min: 4, Max: 2}))
+	assert.ErrorIs(t, err, ErrInvalidAutoscale)
+	_, err = NewPool(-1, WithAutoscale(AutoscaleOptions{Min: 1, Max: 2}))
+	assert.ErrorIs(t, err, ErrInvalidAutoscale)
+	_, err = NewPoolWithFunc(10, func(interface{}) {}, WithPreAlloc(true), WithAutoscale(AutoscaleOptions{Min: 1, Max: 2}))
+	assert.ErrorIs(t, err, ErrInvalidAutoscale)
+}
+
+func TestPoolWithFuncGeneric(t *testing.T) {
+	var sum int32
+	var wg sync.WaitGroup
+	p, err := NewPoolWithFuncGeneric(2, func(n int32) {
+		atomic.AddInt32(&sum, n)
+		wg.Done()
+	}, WithPreAlloc(true))
+	assert.NoError(t, err)
+	defer p.Release()
+
+	for i := int32(1); i <= 10; i++ {
+		wg.Add(1)
+		assert.NoError(t, p.Invoke(i))
+	}
+	wg.Wait()
+	assert.EqualValues(t, 55, atomic.LoadInt32(&sum))
+	assert.Equal(t, 2, p.Cap())
+	assert.EqualValues(t, 10, p.Stats().Submitted)
+
+	// nil arguments don't stop the workers
+	var nils int32
+	q, _ := NewPoolWithFuncGeneric(1, func(err error) {
+		if err == nil {
+			atomic.AddInt32(&nils, 1)
+		}
+		wg.Done()
+	})
+	defer q.Release()
+	wg.Add(2)
+	assert.NoError(t, q.Invoke(nil))
+	assert.NoError(t, q.InvokeWithPriority(nil, 1))
+	wg.Wait()
+	assert.EqualValues(t, 2, atomic.LoadInt32(&nils))
+
+	_, err = NewPoolWithFuncGeneric[int](1, nil)
+	assert.ErrorIs(t, err, ErrLackPoolFunc)
+
+	p.Release()
+	assert.ErrorIs(t, p.Invoke(1), ErrPoolClosed)
+}
+
+func TestPoolWithFuncGenericInvokeCtx(t *testing.T) {
+	release := make(chan struct{})
+	p, _ := NewPoolWithFuncGeneric(1, func(struct{}) { <-release })
+	defer p.Release()
+
+	assert.NoError(t, p.InvokeCtx(context.Background(), struct{}{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.InvokeCtx(ctx, struct{}{}), context.DeadlineExceeded)
+	close(release)
+}
+
+func TestTypedPool(t *testing.T) {
+	p, err := NewTypedPool(2, func(ctx context.Context, s string) (int, error) {
+		if s == "" {
+			return 0, assert.AnError
+		}
+		if s == "panic" {
+			panic("boom")
+		}
+		return len(s), nil
+	}, WithPanicHandler(func(interface{}) {}))
+	assert.NoError(t, err)
+	defer p.Release()
+
+	futures := []*Future[int]{}
+	for _, s := range []string{"a", "bb", "ccc"} {
+		futures = append(futures, p.Invoke(context.Background(), s))
+	}
+	for i, f := range futures {
+		n, err := f.Await()
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, n)
+	}
+
+	_, err = p.InvokeWithPriority(context.Background(), "", 1).Await()
+	assert.ErrorIs(t, err, assert.AnError)
+
+	_, err = p.Invoke(context.Background(), "panic").Await()
+	assert.ErrorIs(t, err, ErrTaskPanicked)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.Invoke(ctx, "a").Await()
+	assert.ErrorIs(t, err, context.Canceled)
+
+	p.Release()
+	_, err = p.Invoke(context.Background(), "a").Await()
+	assert.ErrorIs(t, err, ErrPoolClosed)
+
+	_, err = NewTypedPool[int, int](1, nil)
+	assert.ErrorIs(t, err, ErrLackPoolFunc)
+}
+
+func TestKeyedPool(t *testing.T) {
+	p, _ := NewPool(4)
+	defer p.Release()
+	kp := NewKeyedPool(p, 8)
+
+	var mu sync.Mutex
+	order := map[string][]int{}
+	var running, maxRunning int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for _, key := range []string{"alice", "bob", "carol"} {
+			i, key := i, key
+			wg.Add(1)
+			assert.NoError(t, kp.SubmitKeyed(key, func() {
+				defer wg.Done()
+				current := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Microsecond)
+				atomic.AddInt32(&running, -1)
+
+				mu.Lock()
+				order[key] = append(order[key], i)
+				mu.Unlock()
+			}))
+		}
+	}
+	wg.Wait()
+
+	for _, key := range []string{"alice", "bob", "carol"} {
+		assert.Len(t, order[key], 50)
+		for i, n := range order[key] {
+			assert.Equal(t, i, n)
+		}
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(3))
+
+	assert.Eventually(t, func() bool { return kp.Stats().Active == 0 }, time.Second, time.Millisecond)
+	stats := kp.Stats()
+	assert.Equal(t, 8, stats.Lanes)
+	assert.Len(t, stats.Depths, 8)
+	assert.Zero(t, stats.Queued)
+	assert.Equal(t, DefaultKeyedLanes, NewKeyedPool(p, 0).Stats().Lanes)
+}
+
+func TestKeyedPoolMaxDepth(t *testing.T) {
+	p, _ := NewPool(2, WithMaxBlockingTasks(2))
+	defer p.Release()
+	kp := NewKeyedPool(p, 1)
+
+	release := make(chan struct{})
+	var ran int32
+	task := func() {
+		<-release
+		atomic.AddInt32(&ran, 1)
+	}
+
+	assert.NoError(t, kp.SubmitKeyed("a", task))
+	assert.Eventually(t, func() bool { return kp.Stats().Queued == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, kp.SubmitKeyed("a", task))
+	assert.NoError(t, kp.SubmitKeyed("b", task))
+	assert.ErrorIs(t, kp.SubmitKeyed("c", task), ErrPoolOverload)
+
+	stats := kp.Stats()
+	assert.Equal(t, 1, stats.Active)
+	assert.Equal(t, 2, stats.Queued)
+	assert.Equal(t, 2, stats.MaxDepth)
+	assert.EqualValues(t, 1, stats.Rejected)
+
+	close(release)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&ran) == 3 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return p.Stats().Submitted == 1 }, time.Second, time.Millisecond)
+}
+
+func TestKeyedPoolPanic(t *testing.T) {
+	var panicked int32
+	p, _ := NewPool(1, WithPanicHandler(func(interface{}) {
+		atomic.AddInt32(&panicked, 1)
+	}))
+	defer p.Release()
+	kp := NewKeyedPool(p, 1)
+
+	release := make(chan struct{})
+	var ran int32
+	assert.NoError(t, kp.SubmitKeyed("a", func() {
+		<-release
+		panic("boom")
+	}))
+	assert.NoError(t, kp.SubmitKeyed("a", func() { atomic.AddInt32(&ran, 1) }))
+	close(release)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&ran) == 1 }, time.Second, time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&panicked))
+
+	p.Release()
+	assert.ErrorIs(t, kp.SubmitKeyed("a", func() {}), ErrPoolClosed)
+}
+
+func TestKeyedPoolNonblockingOverload(t *testing.T) {
+	p, _ := NewPool(1, WithNonblocking(true))
+	defer p.Release()
+	kp := NewKeyedPool(p, 1)
+
+	release := make(chan struct{})
+	assert.NoError(t, p.Submit(func() { <-release }))
+
+	// the lane can't get a worker: every task queued on it fails, none is stranded
+	var ran, accepted int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := kp.SubmitKeyed("a", func() { atomic.AddInt32(&ran, 1) })
+			if err == nil {
+				atomic.AddInt32(&accepted, 1)
+			} else {
+				assert.ErrorIs(t, err, ErrPoolOverload)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Zero(t, atomic.LoadInt32(&accepted))
+	stats := kp.Stats()
+	assert.Zero(t, stats.Queued)
+	assert.Zero(t, stats.Active)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return kp.SubmitKeyed("a", func() { atomic.AddInt32(&ran, 1) }) == nil
+	}, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&ran) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestKeyedPoolReleasedWhileStarting(t *testing.T) {
+	p, _ := NewPool(1)
+	kp := NewKeyedPool(p, 1)
+
+	release := make(chan struct{})
+	defer close(release)
+	assert.NoError(t, p.Submit(func() { <-release }))
+
+	// the first task waits for a worker, the next ones are queued behind it
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() { errs <- kp.SubmitKeyed("a", func() {}) }()
+	}
+	assert.Eventually(t, func() bool { return kp.Stats().Queued == 5 }, time.Second, time.Millisecond)
+
+	// the error of the submission of the lane is given to every task queued on it
+	p.Release()
+	err := <-errs
+	assert.Error(t, err)
+	for i := 1; i < 5; i++ {
+		assert.Equal(t, err, <-errs)
+	}
+	assert.Zero(t, kp.Stats().Queued)
+	assert.Zero(t, kp.Stats().Active)
+}
+
+func TestKeyedPoolNonblockingPanic(t *testing.T) {
+	p, _ := NewPool(1, WithNonblocking(true), WithPanicHandler(func(interface{}) {}))
+	defer p.Release()
+	kp := NewKeyedPool(p, 1)
+
+	// the lane resumes on the only worker once the panicking task releases it
+	release := make(chan struct{})
+	var ran int32
+	assert.NoError(t, kp.SubmitKeyed("a", func() {
+		<-release
+		panic("boom")
+	}))
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, kp.SubmitKeyed("a", func() { atomic.AddInt32(&ran, 1) }))
+	}
+	close(release)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&ran) == 10 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return kp.Stats().Active == 0 }, time.Second, time.Millisecond)
+}
+
+func TestKeyedPoolReleasedAfterPanic(t *testing.T) {
+	p, _ := NewPool(1, WithPanicHandler(func(interface{}) {}))
+	kp := NewKeyedPool(p, 1)
+
+	release := make(chan struct{})
+	var ran int32
+	assert.NoError(t, kp.SubmitKeyed("a", func() {
+		<-release
+		panic("boom")
+	}))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, kp.SubmitKeyed("a", func() { atomic.AddInt32(&ran, 1) }))
+	}
+
+	// the lane can't resume on a closed pool, its tasks are dropped instead of retried forever
+	p.Release()
+	close(release)
+	assert.Eventually(t, func() bool { return kp.Stats().Active == 0 }, time.Second, time.Millisecond)
+	stats := kp.Stats()
+	assert.Zero(t, stats.Queued)
+	assert.EqualValues(t, 3, stats.Dropped)
+	assert.Zero(t, atomic.LoadInt32(&ran))
+}
+
+func TestKeyedPoolResumeRetries(t *testing.T) {
+	p, _ := NewPool(2, WithNonblocking(true), WithPanicHandler(func(interface{}) {}))
+	defer p.Release()
+	kp := NewKeyedPool(p, 1)
+
+	// the pool stays full once the first task panics, the lane can't resume
+	hold := make(chan struct{})
+	assert.NoError(t, kp.SubmitKeyed("a", func() {
+		assert.NoError(t, p.Submit(func() { <-hold }))
+		p.Tune(1)
+		panic("boom")
+	}))
+	assert.NoError(t, kp.SubmitKeyed("a", func() {}))
+	assert.Eventually(t, func() bool { return kp.Stats().Dropped == 1 }, 5*time.Second, time.Millisecond)
+	assert.Zero(t, kp.Stats().Active)
+	close(hold)
+
+	assert.Eventually(t, func() bool {
+		return kp.SubmitKeyed("a", func() {}) == nil
+	}, time.Second, time.Millisecond)
+}
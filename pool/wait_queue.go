@@ -0,0 +1,152 @@
+package pool
+
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+// waiter is an invoker blocked until a worker is available.
+type waiter struct {
+	priority int
+
+	// key is the virtual enqueuing time of the waiter: the higher the priority, the earlier the key.
+	// It is only used when aging is enabled.
+	key int64
+
+	// seq keeps the arrival order between waiters having the same priority.
+	seq uint64
+
+	// index is the position of the waiter in the heap, -1 when it is not enqueued.
+	index int
+
+	// ready is closed when the waiter is picked to retry getting a worker.
+	ready chan struct{}
+}
+
+// waitQueue orders the blocked invokers by priority.
+//
+// With aging, a waiter gains one priority level every `aging` period spent in the queue,
+// so that low priority invokers are not starved. Since every waiter ages at the same pace,
+// comparing (priority + waited/aging) between two waiters is the same as comparing
+// (enqueued - priority*aging), which does not depend on the current time: the ordering
+// can be stored in a heap.
+type waitQueue struct {
+	items []*waiter
+	seq   uint64
+	aging time.Duration
+}
+
+func newWaitQueue(aging time.Duration) *waitQueue {
+	return &waitQueue{aging: aging}
+}
+
+func (q *waitQueue) len() int {
+	return len(q.items)
+}
+
+// newWaiter creates a waiter, which keeps its place in line when it is pushed back to the queue.
+func (q *waitQueue) newWaiter(priority int) *waiter {
+	q.seq++
+	w := &waiter{
+		priority: priority,
+		seq:      q.seq,
+		index:    -1,
+	}
+	if q.aging > 0 {
+		w.key = agingKey(time.Now().UnixNano(), priority, q.aging)
+	}
+	return w
+}
+
+// agingKey returns now - priority*aging, saturated to the int64 range so that
+// huge priorities still sort first (or last) instead of wrapping around.
+func agingKey(now int64, priority int, aging time.Duration) int64 {
+	p, a := int64(priority), int64(aging)
+	if p > math.MaxInt64/a {
+		return math.MinInt64
+	}
+	if p < math.MinInt64/a {
+		return math.MaxInt64
+	}
+
+	offset := p * a
+	if offset > 0 && now < math.MinInt64+offset {
+		return math.MinInt64
+	}
+	if offset < 0 && now > math.MaxInt64+offset {
+		return math.MaxInt64
+	}
+	return now - offset
+}
+
+// push enqueues the waiter, with a fresh ready channel.
+func (q *waitQueue) push(w *waiter) {
+	w.ready = make(chan struct{})
+	heap.Push(q, w)
+}
+
+// remove dequeues the waiter, if it is still enqueued.
+func (q *waitQueue) remove(w *waiter) {
+	if w.index >= 0 {
+		heap.Remove(q, w.index)
+	}
+}
+
+// notifyOne wakes up the first waiter in line, it returns false when the queue is empty.
+func (q *waitQueue) notifyOne() bool {
+	if len(q.items) == 0 {
+		return false
+	}
+	w := heap.Pop(q).(*waiter)
+	close(w.ready)
+	return true
+}
+
+// notifyAll wakes up every waiter.
+func (q *waitQueue) notifyAll() {
+	for q.notifyOne() {
+	}
+}
+
+// Len implements heap.Interface.
+func (q *waitQueue) Len() int {
+	return len(q.items)
+}
+
+// Less implements heap.Interface.
+func (q *waitQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if q.aging > 0 {
+		if a.key != b.key {
+			return a.key < b.key
+		}
+	} else if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
+
+// Swap implements heap.Interface.
+func (q *waitQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+// Push implements heap.Interface.
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(q.items)
+	q.items = append(q.items, w)
+}
+
+// Pop implements heap.Interface.
+func (q *waitQueue) Pop() interface{} {
+	n := len(q.items)
+	w := q.items[n-1]
+	q.items[n-1] = nil // avoid memory leaks
+	q.items = q.items[:n-1]
+	w.index = -1
+	return w
+}
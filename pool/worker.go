@@ -23,26 +23,36 @@ type goWorker struct {
 // that performs the function calls.
 func (w *goWorker) run() {
 	w.pool.addRunning(1)
+	w.pool.metrics.workerSpawned()
 	go func() {
+		// start is the starting time of the running task, it is zero between the tasks.
+		var start time.Time
+
 		defer func() {
 			w.pool.addRunning(-1)
 			w.pool.workerCache.Put(w)
 			if p := recover(); p != nil {
+				if !start.IsZero() {
+					w.pool.metrics.taskDone(time.Since(start), true)
+				}
 				if ph := w.pool.options.PanicHandler; ph != nil {
 					ph(p)
 				} else {
 					w.pool.options.Logger.Printf("worker exits from panic: %v\n%s\n", p, debug.Stack())
 				}
 			}
-			// Call Signal() here in case there are goroutines waiting for available workers.
-			w.pool.cond.Signal()
+			// Wake up an invoker here in case there are goroutines waiting for available workers.
+			w.pool.wakeWaiter()
 		}()
 
 		for f := range w.task {
 			if f == nil {
 				return
 			}
+			start = time.Now()
 			f()
+			w.pool.metrics.taskDone(time.Since(start), false)
+			start = time.Time{}
 			if ok := w.pool.revertWorker(w); !ok {
 				return
 			}
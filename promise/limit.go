@@ -0,0 +1,152 @@
+package promise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/pool"
+)
+
+// Thunk is a lazy promise: unlike New, nothing runs until a combinator such as AllLimit calls it.
+type Thunk[T any] func(ctx context.Context) (T, error)
+
+// LimitOption configures AllLimit and MapLimit.
+type LimitOption func(*limitConfig)
+
+type limitConfig struct {
+	pool       *pool.Pool
+	collectAll bool
+}
+
+// WithPool runs the thunks on the workers of p instead of new goroutines.
+func WithPool(p *pool.Pool) LimitOption {
+	return func(c *limitConfig) {
+		c.pool = p
+	}
+}
+
+// WithCollectErrors runs every thunk even when some of them fail, and rejects with all the errors
+// joined in order. By default the first error rejects the promise and cancels the other thunks.
+func WithCollectErrors() LimitOption {
+	return func(c *limitConfig) {
+		c.collectAll = true
+	}
+}
+
+// AllLimit runs the thunks with at most n of them at once, and resolves with their results in order.
+// A limit n <= 0 runs all of them at once. An empty list of thunks resolves with an empty slice.
+func AllLimit[T any](ctx context.Context, n int, thunks []Thunk[T], opts ...LimitOption) *Promise[[]T] {
+	c := &limitConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return NewWithContext(ctx, func(ctx context.Context, resolve func([]T), reject func(error)) {
+		results, err := runLimit(ctx, n, thunks, c)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(results)
+	})
+}
+
+// MapLimit calls fn on each item with at most n calls at once, and resolves with the results in
+// the order of the items. See AllLimit.
+func MapLimit[T, U any](ctx context.Context, items []T, n int, fn func(ctx context.Context, item T) (U, error), opts ...LimitOption) *Promise[[]U] {
+	thunks := make([]Thunk[U], len(items))
+	for i, item := range items {
+		item := item
+		thunks[i] = func(ctx context.Context) (U, error) {
+			return fn(ctx, item)
+		}
+	}
+	return AllLimit(ctx, n, thunks, opts...)
+}
+
+func runLimit[T any](ctx context.Context, n int, thunks []Thunk[T], c *limitConfig) ([]T, error) {
+	if n <= 0 || n > len(thunks) {
+		n = len(thunks)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]T, len(thunks))
+	errs := make([]error, len(thunks))
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		if c.collectAll {
+			return
+		}
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	sem := make(chan struct{}, n)
+	for i, thunk := range thunks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			// the remaining thunks never run
+			for j := i; j < len(thunks); j++ {
+				errs[j] = err
+			}
+			break
+		}
+
+		i, thunk := i, thunk
+		task := func() {
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = errors.New(fmt.Sprint(r))
+					fail(errs[i])
+				}
+				<-sem
+				wg.Done()
+			}()
+
+			results[i], errs[i] = thunk(ctx)
+			if errs[i] != nil {
+				fail(errs[i])
+			}
+		}
+
+		wg.Add(1)
+		if c.pool == nil {
+			go task()
+			continue
+		}
+		err := c.pool.SubmitCtx(ctx, func(context.Context) error {
+			task()
+			return nil
+		})
+		if err != nil {
+			errs[i] = err
+			fail(err)
+			<-sem
+			wg.Done()
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := internal.JoinError(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,118 @@
+package promise
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/pool"
+)
+
+func TestMapLimit(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestMapLimit")
+
+	var running, maxRunning int32
+	square := func(ctx context.Context, i int) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(10-i) * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return i * i, nil
+	}
+
+	items := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	results, err := MapLimit(context.Background(), items, 3, square).Await()
+	assert.IsNil(err)
+	assert.Equal([]int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}, results)
+	assert.ShouldBeTrue(atomic.LoadInt32(&maxRunning) <= 3)
+
+	results, err = MapLimit(context.Background(), []int{}, 3, square).Await()
+	assert.IsNil(err)
+	assert.Equal(0, len(results))
+}
+
+func TestMapLimitFailFast(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestMapLimitFailFast")
+
+	var calls int32
+	fn := func(ctx context.Context, i int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		if i == 1 {
+			return 0, errors.New("error1")
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Second):
+			return i, nil
+		}
+	}
+
+	start := time.Now()
+	_, err := MapLimit(context.Background(), []int{0, 1, 2, 3, 4, 5}, 2, fn).Await()
+	assert.Equal("error1", err.Error())
+	assert.ShouldBeTrue(time.Since(start) < 500*time.Millisecond)
+	assert.ShouldBeTrue(atomic.LoadInt32(&calls) < 6)
+}
+
+func TestMapLimitCollectErrors(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestMapLimitCollectErrors")
+
+	var calls int32
+	fn := func(ctx context.Context, i int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		if i%2 == 1 {
+			return 0, errors.New("error" + string(rune('0'+i)))
+		}
+		return i, nil
+	}
+
+	_, err := MapLimit(context.Background(), []int{0, 1, 2, 3, 4}, 2, fn, WithCollectErrors()).Await()
+	assert.IsNotNil(err)
+	assert.Equal(int32(5), atomic.LoadInt32(&calls))
+	assert.ShouldBeTrue(strings.Index(err.Error(), "error1") < strings.Index(err.Error(), "error3"))
+}
+
+func TestAllLimit(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestAllLimit")
+
+	p, err := pool.NewPool(2)
+	assert.IsNil(err)
+	defer p.Release()
+
+	thunks := []Thunk[string]{
+		func(ctx context.Context) (string, error) { return "a", nil },
+		func(ctx context.Context) (string, error) { return "b", nil },
+		func(ctx context.Context) (string, error) { return "c", nil },
+	}
+	results, err := AllLimit(context.Background(), 0, thunks, WithPool(p)).Await()
+	assert.IsNil(err)
+	assert.Equal([]string{"a", "b", "c"}, results)
+
+	// a panic rejects the promise
+	thunks = append(thunks, func(ctx context.Context) (string, error) { panic("boom") })
+	_, err = AllLimit(context.Background(), 2, thunks).Await()
+	assert.Equal("boom", err.Error())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = AllLimit(ctx, 2, thunks).Await()
+	assert.Equal(context.Canceled, err)
+}
@@ -2,9 +2,11 @@
 package promise
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/sllt/af/internal"
 )
@@ -21,35 +23,98 @@ type Promise[T any] struct {
 
 	mu *sync.Mutex
 	wg *sync.WaitGroup
+
+	// done is closed when the promise is settled.
+	done chan struct{}
+
+	// cancel cancels the context of the promise, it does nothing when it has no context.
+	cancel func()
+	// release frees the context of the promise once it is settled.
+	release context.CancelFunc
 }
 
+// ErrTimeout is the error of the promises returned by Timeout when the timeout expires.
+var ErrTimeout = fmt.Errorf("promise: timeout: %w", context.DeadlineExceeded)
+
 // New create a new promise instance.
 func New[T any](runnable func(resolve func(T), reject func(error))) *Promise[T] {
 	if runnable == nil {
 		panic("runnable function should not be nil")
 	}
 
-	p := &Promise[T]{
-		runnable: runnable,
-		pending:  true,
-		mu:       &sync.Mutex{},
-		wg:       &sync.WaitGroup{},
+	p := newPending[T]()
+	p.runnable = runnable
+
+	defer p.run()
+
+	return p
+}
+
+// NewWithContext create a new promise instance, whose runnable receives a context.
+// The promise is rejected with ctx.Err() as soon as ctx is done, or when Cancel is called,
+// even if the runnable ignores its context.
+func NewWithContext[T any](ctx context.Context, runnable func(ctx context.Context, resolve func(T), reject func(error))) *Promise[T] {
+	if runnable == nil {
+		panic("runnable function should not be nil")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	p := newPending[T]()
+	p.cancel = cancel
+	p.release = cancel
+	p.runnable = func(resolve func(T), reject func(error)) {
+		runnable(ctx, resolve, reject)
+	}
+
+	if err := ctx.Err(); err != nil {
+		p.reject(err)
+		return p
 	}
 
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.reject(ctx.Err())
+		case <-p.done:
+		}
+	}()
+
 	defer p.run()
 
 	return p
 }
 
-func (p *Promise[T]) run() {
+// newPending returns a pending promise, the counter of its wait group is already incremented
+// so that it may be settled at once, even before its runnable is started.
+func newPending[T any]() *Promise[T] {
+	p := &Promise[T]{
+		pending: true,
+		mu:      &sync.Mutex{},
+		wg:      &sync.WaitGroup{},
+		done:    make(chan struct{}),
+		cancel:  func() {},
+	}
 	p.wg.Add(1)
+	return p
+}
 
+func newSettled[T any](result T, err error) *Promise[T] {
+	p := &Promise[T]{
+		result: result,
+		err:    err,
+		mu:     &sync.Mutex{},
+		wg:     &sync.WaitGroup{},
+		done:   make(chan struct{}),
+		cancel: func() {},
+	}
+	close(p.done)
+	return p
+}
+
+func (p *Promise[T]) run() {
 	go func() {
 		defer func() {
-			if !p.pending {
-				return
-			}
-
 			if err := recover(); err != nil {
 				p.reject(errors.New(fmt.Sprint(err)))
 			}
@@ -61,12 +126,7 @@ func (p *Promise[T]) run() {
 
 // Resolve returns a Promise that has been resolved with a given value.
 func Resolve[T any](resolution T) *Promise[T] {
-	return &Promise[T]{
-		result:  resolution,
-		pending: false,
-		mu:      &sync.Mutex{},
-		wg:      &sync.WaitGroup{},
-	}
+	return newSettled(resolution, nil)
 }
 
 func (p *Promise[T]) resolve(value T) {
@@ -78,19 +138,13 @@ func (p *Promise[T]) resolve(value T) {
 	}
 
 	p.result = value
-	p.pending = false
-
-	p.wg.Done()
+	p.settle()
 }
 
 // Reject returns a Promise that has been rejected with a given error.
 func Reject[T any](err error) *Promise[T] {
-	return &Promise[T]{
-		err:     err,
-		pending: false,
-		mu:      &sync.Mutex{},
-		wg:      &sync.WaitGroup{},
-	}
+	var zero T
+	return newSettled(zero, err)
 }
 
 func (p *Promise[T]) reject(err error) {
@@ -102,11 +156,32 @@ func (p *Promise[T]) reject(err error) {
 	}
 
 	p.err = err
+	p.settle()
+}
+
+// settle marks the promise as settled, p.mu must be held.
+func (p *Promise[T]) settle() {
 	p.pending = false
+	close(p.done)
+	if p.release != nil {
+		p.release()
+	}
 
 	p.wg.Done()
 }
 
+// Cancel cancels the context of a promise created by NewWithContext, a pending promise is rejected
+// with context.Canceled. A promise created by New has no context: Cancel does nothing, and the
+// promise is settled by its runnable.
+func (p *Promise[T]) Cancel() {
+	p.cancel()
+}
+
+// Done returns a channel that is closed when the promise is settled.
+func (p *Promise[T]) Done() <-chan struct{} {
+	return p.done
+}
+
 // Then allows chain calls to other promise methods.
 func Then[T1, T2 any](promise *Promise[T1], resolve1 func(value T1) T2) *Promise[T2] {
 	return New(func(resolve2 func(T2), reject func(error)) {
@@ -131,6 +206,59 @@ func (p *Promise[T]) Then(resolve func(value T) T) *Promise[T] {
 	})
 }
 
+// ThenErr allows chain calls to other promise methods, with a function which may fail.
+func ThenErr[T1, T2 any](promise *Promise[T1], resolve1 func(value T1) (T2, error)) *Promise[T2] {
+	return New(func(resolve2 func(T2), reject func(error)) {
+		result, err := promise.Await()
+		if err != nil {
+			reject(err)
+			return
+		}
+
+		value, err := resolve1(result)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve2(value)
+	})
+}
+
+// Finally calls fn once the promise is settled, and returns a promise settled the same way.
+func (p *Promise[T]) Finally(fn func()) *Promise[T] {
+	return New(func(resolve func(T), reject func(error)) {
+		result, err := p.Await()
+		fn()
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(result)
+	})
+}
+
+// Timeout returns a promise settled like the given promise, or rejected with ErrTimeout
+// when it is not settled in time. The given promise is canceled on timeout, see Cancel.
+func Timeout[T any](promise *Promise[T], timeout time.Duration) *Promise[T] {
+	return New(func(resolve func(T), reject func(error)) {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-promise.Done():
+			result, err := promise.Await()
+			if err != nil {
+				reject(err)
+				return
+			}
+			resolve(result)
+		case <-timer.C:
+			promise.Cancel()
+			reject(ErrTimeout)
+		}
+	})
+}
+
 // Catch allows to chain promises.
 func Catch[T any](promise *Promise[T], rejection func(err error) error) *Promise[T] {
 	return New(func(resolve func(T), reject func(error)) {
@@ -166,7 +294,14 @@ type tuple[T1, T2 any] struct {
 	_2 T2
 }
 
+// Result is the outcome of a settled promise, see AllSettled.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
 // All resolves when all of the promises have resolved, reject immediately upon any of the input promises rejecting.
+// The other promises are canceled when one of them rejects, see Cancel.
 func All[T any](promises []*Promise[T]) *Promise[[]T] {
 	if len(promises) == 0 {
 		return nil
@@ -174,7 +309,7 @@ func All[T any](promises []*Promise[T]) *Promise[[]T] {
 
 	return New(func(resolve func([]T), reject func(error)) {
 		valsChan := make(chan tuple[T, int], len(promises))
-		errsChan := make(chan error, 1)
+		errsChan := make(chan error, len(promises))
 
 		for idx, p := range promises {
 			idx := idx
@@ -195,6 +330,7 @@ func All[T any](promises []*Promise[T]) *Promise[[]T] {
 				resolutions[val._2] = val._1
 			case err := <-errsChan:
 				reject(err)
+				cancelAll(promises)
 				return
 			}
 		}
@@ -202,15 +338,33 @@ func All[T any](promises []*Promise[T]) *Promise[[]T] {
 	})
 }
 
+// AllSettled resolves when all of the promises are settled, with the value or the error of each promise.
+// It never rejects.
+func AllSettled[T any](promises []*Promise[T]) *Promise[[]Result[T]] {
+	if len(promises) == 0 {
+		return nil
+	}
+
+	return New(func(resolve func([]Result[T]), reject func(error)) {
+		results := make([]Result[T], len(promises))
+		for idx, p := range promises {
+			value, err := p.Await()
+			results[idx] = Result[T]{Value: value, Err: err}
+		}
+		resolve(results)
+	})
+}
+
 // Race will settle the first fullfiled promise among muti promises.
+// The other promises are canceled once it is settled, see Cancel.
 func Race[T any](promises []*Promise[T]) *Promise[T] {
 	if len(promises) == 0 {
 		return nil
 	}
 
 	return New(func(resolve func(T), reject func(error)) {
-		valsChan := make(chan T, 1)
-		errsChan := make(chan error, 1)
+		valsChan := make(chan T, len(promises))
+		errsChan := make(chan error, len(promises))
 
 		for _, p := range promises {
 			_ = Then(p, func(data T) T {
@@ -229,18 +383,20 @@ func Race[T any](promises []*Promise[T]) *Promise[T] {
 		case err := <-errsChan:
 			reject(err)
 		}
+		cancelAll(promises)
 	})
 }
 
 // Any resolves as soon as any of the input's Promises resolve, with the value of the resolved Promise.
 // Any rejects if all of the given Promises are rejected with a combination of all errors.
+// The other promises are canceled once one of them resolves, see Cancel.
 func Any[T any](promises []*Promise[T]) *Promise[T] {
 	if len(promises) == 0 {
 		return nil
 	}
 
 	return New(func(resolve func(T), reject func(error)) {
-		valsChan := make(chan T, 1)
+		valsChan := make(chan T, len(promises))
 		errsChan := make(chan tuple[error, int], len(promises))
 
 		for idx, p := range promises {
@@ -260,17 +416,20 @@ func Any[T any](promises []*Promise[T]) *Promise[T] {
 			select {
 			case val := <-valsChan:
 				resolve(val)
+				cancelAll(promises)
 				return
 			case err := <-errsChan:
 				errs[err._2] = err._1
 			}
 		}
 
-		errCombo := errs[0]
-		for _, err := range errs[1:] {
-			errCombo = internal.JoinError(err)
-		}
-
-		reject(errCombo)
+		reject(internal.JoinError(errs...))
 	})
 }
+
+// cancelAll cancels the promises created by NewWithContext, the settled ones are left unchanged.
+func cancelAll[T any](promises []*Promise[T]) {
+	for _, p := range promises {
+		p.Cancel()
+	}
+}
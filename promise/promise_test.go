@@ -1,7 +1,9 @@
 package promise
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -310,3 +312,220 @@ func TestRace(t *testing.T) {
 	})
 
 }
+
+func TestNewWithContext(t *testing.T) {
+	assert := internal.NewAssert(t, "TestNewWithContext")
+
+	t.Run("Resolved", func(_ *testing.T) {
+		p := NewWithContext(context.Background(), func(ctx context.Context, resolve func(string), reject func(error)) {
+			resolve("abc")
+		})
+
+		val, err := p.Await()
+		assert.Equal("abc", val)
+		assert.IsNil(err)
+	})
+
+	t.Run("Canceled", func(_ *testing.T) {
+		stopped := make(chan struct{})
+		p := NewWithContext(context.Background(), func(ctx context.Context, resolve func(string), reject func(error)) {
+			<-ctx.Done()
+			close(stopped)
+		})
+		p.Cancel()
+
+		_, err := p.Await()
+		assert.Equal(context.Canceled, err)
+		<-stopped
+	})
+
+	t.Run("Deadline", func(_ *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// the runnable ignores its context
+		p := NewWithContext(ctx, func(ctx context.Context, resolve func(string), reject func(error)) {
+			time.Sleep(500 * time.Millisecond)
+			resolve("slow")
+		})
+
+		_, err := p.Await()
+		assert.Equal(context.DeadlineExceeded, err)
+	})
+
+	t.Run("AlreadyDone", func(_ *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ran := false
+		p := NewWithContext(ctx, func(ctx context.Context, resolve func(string), reject func(error)) {
+			ran = true
+		})
+
+		_, err := p.Await()
+		assert.Equal(context.Canceled, err)
+		assert.ShouldBeFalse(ran)
+	})
+
+	t.Run("DoneWhileCreated", func(_ *testing.T) {
+		// the context may expire before the runnable is started
+		for i := 0; i < 5000; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i%2000))
+			p := NewWithContext(ctx, func(ctx context.Context, resolve func(int), reject func(error)) {
+				resolve(1)
+			})
+			_, _ = p.Await()
+			cancel()
+		}
+	})
+}
+
+func TestThenErr(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestThenErr")
+
+	p := ThenErr(Resolve("12"), func(s string) (int, error) {
+		if s == "" {
+			return 0, errors.New("empty")
+		}
+		return len(s), nil
+	})
+	val, err := p.Await()
+	assert.Equal(2, val)
+	assert.IsNil(err)
+
+	p = ThenErr(Resolve(""), func(s string) (int, error) {
+		return 0, errors.New("empty")
+	})
+	_, err = p.Await()
+	assert.Equal("empty", err.Error())
+
+	called := false
+	p = ThenErr(Reject[string](errors.New("error")), func(s string) (int, error) {
+		called = true
+		return 0, nil
+	})
+	_, err = p.Await()
+	assert.Equal("error", err.Error())
+	assert.ShouldBeFalse(called)
+}
+
+func TestPromise_Finally(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestPromise_Finally")
+
+	calls := 0
+	val, err := Resolve("abc").Finally(func() { calls++ }).Await()
+	assert.Equal("abc", val)
+	assert.IsNil(err)
+
+	_, err = Reject[string](errors.New("error")).Finally(func() { calls++ }).Await()
+	assert.Equal("error", err.Error())
+	assert.Equal(2, calls)
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestTimeout")
+
+	slow := New(func(resolve func(string), reject func(error)) {
+		time.Sleep(500 * time.Millisecond)
+		resolve("slow")
+	})
+	_, err := Timeout(slow, 50*time.Millisecond).Await()
+	assert.Equal(ErrTimeout, err)
+	assert.ShouldBeTrue(errors.Is(err, context.DeadlineExceeded))
+
+	// a promise created by New is settled by its runnable
+	val, err := slow.Await()
+	assert.Equal("slow", val)
+	assert.IsNil(err)
+
+	// a promise created by NewWithContext is canceled
+	withContext := NewWithContext(context.Background(), func(ctx context.Context, resolve func(string), reject func(error)) {
+		<-ctx.Done()
+	})
+	_, err = Timeout(withContext, 50*time.Millisecond).Await()
+	assert.Equal(ErrTimeout, err)
+	_, err = withContext.Await()
+	assert.Equal(context.Canceled, err)
+
+	val, err = Timeout(Resolve("fast"), time.Second).Await()
+	assert.Equal("fast", val)
+	assert.IsNil(err)
+}
+
+func TestAllSettled(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestAllSettled")
+
+	p1 := New(func(resolve func(string), reject func(error)) {
+		time.Sleep(50 * time.Millisecond)
+		resolve("a")
+	})
+	p2 := Reject[string](errors.New("error"))
+	p3 := Resolve("c")
+
+	results, err := AllSettled([]*Promise[string]{p1, p2, p3}).Await()
+	assert.IsNil(err)
+	assert.Equal(3, len(results))
+	assert.Equal(Result[string]{Value: "a"}, results[0])
+	assert.Equal("error", results[1].Err.Error())
+	assert.Equal(Result[string]{Value: "c"}, results[2])
+
+	assert.IsNil(AllSettled([]*Promise[string]{}))
+}
+
+func TestCancelLosers(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestCancelLosers")
+
+	newSlow := func() (*Promise[string], chan struct{}) {
+		stopped := make(chan struct{})
+		return NewWithContext(context.Background(), func(ctx context.Context, resolve func(string), reject func(error)) {
+			<-ctx.Done()
+			close(stopped)
+		}), stopped
+	}
+
+	slow, stopped := newSlow()
+	val, err := Race([]*Promise[string]{Resolve("fast"), slow}).Await()
+	assert.Equal("fast", val)
+	assert.IsNil(err)
+	<-stopped
+
+	slow, stopped = newSlow()
+	val, err = Any([]*Promise[string]{Reject[string](errors.New("error")), Resolve("fast"), slow}).Await()
+	assert.Equal("fast", val)
+	assert.IsNil(err)
+	<-stopped
+
+	slow, stopped = newSlow()
+	_, err = All([]*Promise[string]{slow, Reject[string](errors.New("error"))}).Await()
+	assert.Equal("error", err.Error())
+	<-stopped
+
+	// the promises created by New are settled by their runnable
+	release := make(chan struct{})
+	loser := New(func(resolve func(string), reject func(error)) {
+		<-release
+		resolve("slow")
+	})
+	val, err = Race([]*Promise[string]{Resolve("fast"), loser}).Await()
+	assert.Equal("fast", val)
+	assert.IsNil(err)
+	close(release)
+	val, err = loser.Await()
+	assert.Equal("slow", val)
+	assert.IsNil(err)
+
+	// every error is kept when all the promises reject
+	_, err = Any([]*Promise[string]{Reject[string](errors.New("error1")), Reject[string](errors.New("error2"))}).Await()
+	assert.ShouldBeTrue(strings.Contains(err.Error(), "error1"))
+	assert.ShouldBeTrue(strings.Contains(err.Error(), "error2"))
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// GCRA is a limiter implementing the generic cell rate algorithm. It behaves like a token bucket,
+// but it only stores the theoretical arrival time of the next event instead of a number of tokens.
+type GCRA struct {
+	clock Clock
+
+	// emission is the interval between two events at the sustained rate, rounded down to the
+	// nanosecond. The rest of the interval is rem/limit nanosecond.
+	emission time.Duration
+	rem      int64
+	limit    int64
+	// tolerance is how far ahead of the sustained rate an event may happen, it allows bursts.
+	tolerance time.Duration
+
+	mu  sync.Mutex
+	tat time.Time
+	// frac is the fraction of nanosecond of tat, in 1/limit nanosecond.
+	frac int64
+}
+
+// NewGCRA creates a GCRA limiter allowing limit events per period, with bursts up to burst events.
+func NewGCRA(limit int, period time.Duration, burst int, opts ...Option) *GCRA {
+	c := newConfig(opts...)
+
+	g := &GCRA{clock: c.clock}
+	if limit > 0 && period > 0 && burst > 0 {
+		// the fractions of nanosecond are kept, so that a limit above one event per nanosecond
+		// of the period is not rounded down to no event at all
+		g.limit = int64(limit)
+		g.emission = period / time.Duration(limit)
+		g.rem = int64(period % time.Duration(limit))
+		g.tolerance = g.emission*time.Duration(burst-1) +
+			time.Duration(float64(g.rem)*float64(burst-1)/float64(limit))
+	}
+	return g
+}
+
+// Allow implements Limiter.
+func (g *GCRA) Allow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.limit == 0 {
+		return false
+	}
+
+	now := g.clock.Now()
+	tat, frac := g.arrival(now)
+	if tat.Sub(now) > g.tolerance {
+		return false
+	}
+	g.tat, g.frac = g.next(tat, frac)
+	return true
+}
+
+// Wait implements Limiter.
+func (g *GCRA) Wait(ctx context.Context) error {
+	return wait(ctx, g.Reserve())
+}
+
+// Reserve implements Limiter.
+func (g *GCRA) Reserve() *Reservation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	r := &Reservation{clock: g.clock, timeToAct: now}
+	if g.limit == 0 {
+		return r
+	}
+
+	tat, frac := g.arrival(now)
+	r.ok = true
+	if allowAt := tat.Add(-g.tolerance); allowAt.After(now) {
+		r.timeToAct = allowAt
+	}
+	g.tat, g.frac = g.next(tat, frac)
+
+	r.cancel = func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		g.tat = g.tat.Add(-g.emission)
+		g.frac -= g.rem
+		if g.frac < 0 {
+			g.frac += g.limit
+			g.tat = g.tat.Add(-time.Nanosecond)
+		}
+		if now := g.clock.Now(); g.tat.Before(now) {
+			g.tat, g.frac = now, 0
+		}
+	}
+	return r
+}
+
+// arrival returns the theoretical arrival time of the next event, with its fraction of nanosecond.
+func (g *GCRA) arrival(now time.Time) (time.Time, int64) {
+	if g.tat.Before(now) {
+		return now, 0
+	}
+	return g.tat, g.frac
+}
+
+// next returns the theoretical arrival time following tat, with its fraction of nanosecond.
+func (g *GCRA) next(tat time.Time, frac int64) (time.Time, int64) {
+	tat = tat.Add(g.emission)
+	frac += g.rem
+	if frac >= g.limit {
+		frac -= g.limit
+		tat = tat.Add(time.Nanosecond)
+	}
+	return tat, frac
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	mapx "github.com/sllt/af/mapx"
+)
+
+// keyedEntry is the limiter of a key.
+type keyedEntry struct {
+	limiter Limiter
+
+	mu       sync.Mutex
+	lastUsed time.Time
+}
+
+func (e *keyedEntry) touch(now time.Time) {
+	e.mu.Lock()
+	e.lastUsed = now
+	e.mu.Unlock()
+}
+
+func (e *keyedEntry) idleSince(now time.Time, idle time.Duration) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return now.Sub(e.lastUsed) >= idle
+}
+
+// Keyed gives each key its own limiter, for example one per user or per remote host.
+//
+// The limiters of the keys not used for the idle timeout are evicted, while the limiters
+// are in use. The idle timeout should be long enough for an evicted limiter to be back to
+// its initial state, for example longer than the period of a token bucket.
+type Keyed[K comparable] struct {
+	limiters *mapx.ConcurrentMap[K, *keyedEntry]
+	factory  func() Limiter
+	idle     time.Duration
+	clock    Clock
+
+	mu        sync.Mutex
+	lastEvict time.Time
+}
+
+// NewKeyed creates a keyed limiter, factory creates the limiter of a new key.
+// An idle timeout of 0 disables the eviction.
+func NewKeyed[K comparable](factory func() Limiter, idle time.Duration, opts ...Option) *Keyed[K] {
+	c := newConfig(opts...)
+
+	return &Keyed[K]{
+		limiters:  mapx.NewConcurrentMap[K, *keyedEntry](0),
+		factory:   factory,
+		idle:      idle,
+		clock:     c.clock,
+		lastEvict: c.clock.Now(),
+	}
+}
+
+// Allow reports whether an event of the key may happen now, and consumes it if so.
+func (k *Keyed[K]) Allow(key K) bool {
+	return k.get(key).Allow()
+}
+
+// Wait blocks until an event of the key is allowed, or until ctx is done.
+func (k *Keyed[K]) Wait(ctx context.Context, key K) error {
+	return k.get(key).Wait(ctx)
+}
+
+// Reserve reserves an event of the key.
+func (k *Keyed[K]) Reserve(key K) *Reservation {
+	return k.get(key).Reserve()
+}
+
+// Limiter returns the limiter of the key, creating it if needed.
+func (k *Keyed[K]) Limiter(key K) Limiter {
+	return k.get(key)
+}
+
+// Len returns the number of keys having a limiter.
+func (k *Keyed[K]) Len() int {
+	n := 0
+	k.limiters.Range(func(K, *keyedEntry) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Evict removes the limiters of the idle keys, and returns how many were removed.
+func (k *Keyed[K]) Evict() int {
+	if k.idle <= 0 {
+		return 0
+	}
+
+	now := k.clock.Now()
+
+	// The map can't be modified while ranging over it.
+	idle := []K{}
+	k.limiters.Range(func(key K, e *keyedEntry) bool {
+		if e.idleSince(now, k.idle) {
+			idle = append(idle, key)
+		}
+		return true
+	})
+
+	n := 0
+	for _, key := range idle {
+		if k.limiters.DeleteIf(key, func(e *keyedEntry) bool { return e.idleSince(now, k.idle) }) {
+			n++
+		}
+	}
+	return n
+}
+
+func (k *Keyed[K]) get(key K) Limiter {
+	now := k.clock.Now()
+	k.maybeEvict(now)
+
+	e, ok := k.limiters.Get(key)
+	if !ok {
+		e, _ = k.limiters.GetOrSet(key, &keyedEntry{limiter: k.factory(), lastUsed: now})
+	}
+	e.touch(now)
+	return e.limiter
+}
+
+// maybeEvict evicts the idle keys once per idle timeout.
+func (k *Keyed[K]) maybeEvict(now time.Time) {
+	if k.idle <= 0 {
+		return
+	}
+
+	k.mu.Lock()
+	if now.Sub(k.lastEvict) < k.idle {
+		k.mu.Unlock()
+		return
+	}
+	k.lastEvict = now
+	k.mu.Unlock()
+
+	k.Evict()
+}
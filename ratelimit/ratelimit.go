@@ -0,0 +1,132 @@
+// Package ratelimit implements rate limiters: token bucket, GCRA and sliding window log,
+// and a keyed limiter giving each key its own limiter.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrWaitExceedsDeadline is returned by Wait when the context deadline would be exceeded before the event is allowed.
+	ErrWaitExceedsDeadline = errors.New("ratelimit: wait would exceed context deadline")
+
+	// ErrNeverAllowed is returned by Wait when the limiter can never allow the event, for example a limit of 0.
+	ErrNeverAllowed = errors.New("ratelimit: event can never be allowed")
+)
+
+// Limiter controls how frequently events are allowed to happen.
+type Limiter interface {
+	// Allow reports whether an event may happen now, and consumes it if so.
+	Allow() bool
+
+	// Wait blocks until an event is allowed, or until ctx is done.
+	// It returns ErrWaitExceedsDeadline at once when the event would not be allowed before the ctx deadline.
+	Wait(ctx context.Context) error
+
+	// Reserve reserves an event, which may happen after the delay of the reservation.
+	// The reservation can be canceled if the event does not happen.
+	Reserve() *Reservation
+}
+
+// Clock drives the time logic of the limiters.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SystemClock is the clock of the limiters created without the WithClock option.
+var SystemClock Clock = systemClock{}
+
+// Option configures a limiter.
+type Option func(*config)
+
+type config struct {
+	clock Clock
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{clock: SystemClock}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithClock sets the clock of the limiter, it allows deterministic tests.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// Reservation is an event reserved on a limiter.
+type Reservation struct {
+	ok        bool
+	timeToAct time.Time
+	clock     Clock
+	cancel    func()
+}
+
+// OK reports whether the event can happen: a reservation is not OK when the limiter
+// can never allow the event, for example a limit of 0.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns the time to wait before the event happens, 0 means now.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return 0
+	}
+	delay := r.timeToAct.Sub(r.clock.Now())
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// Cancel gives the reserved event back to the limiter, as far as possible.
+// It must be called when the event does not happen.
+func (r *Reservation) Cancel() {
+	if r.ok && r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// wait blocks until the reservation delay is over, it cancels the reservation when ctx is done first.
+func wait(ctx context.Context, r *Reservation) error {
+	if !r.ok {
+		return ErrNeverAllowed
+	}
+
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(r.timeToAct) {
+		r.Cancel()
+		return ErrWaitExceedsDeadline
+	}
+
+	select {
+	case <-r.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,273 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/internal"
+)
+
+// fakeClock is a clock whose time only moves with Add.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), c: ch})
+	return ch
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			timers = append(timers, t)
+		} else {
+			t.c <- c.now
+		}
+	}
+	c.timers = timers
+}
+
+func (c *fakeClock) pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func allowed(l Limiter, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if l.Allow() {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestTokenBucket")
+
+	clock := newFakeClock()
+	b := NewTokenBucket(10, time.Second, 5, WithClock(clock))
+
+	assert.Equal(5, allowed(b, 10))
+
+	clock.Add(200 * time.Millisecond)
+	assert.Equal(2, allowed(b, 10))
+
+	clock.Add(time.Hour)
+	assert.Equal(5.0, b.Tokens())
+
+	// reservations go ahead of the refill
+	assert.Equal(5, allowed(b, 5))
+	r := b.Reserve()
+	assert.ShouldBeTrue(r.OK())
+	assert.Equal(100*time.Millisecond, r.Delay())
+	r2 := b.Reserve()
+	assert.Equal(200*time.Millisecond, r2.Delay())
+
+	r2.Cancel()
+	r2.Cancel()
+	assert.Equal(-1.0, b.Tokens())
+
+	r = NewTokenBucket(0, time.Second, 0, WithClock(clock)).Reserve()
+	assert.ShouldBeFalse(r.OK())
+	assert.Equal(time.Duration(0), r.Delay())
+}
+
+func TestGCRA(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestGCRA")
+
+	clock := newFakeClock()
+	g := NewGCRA(10, time.Second, 5, WithClock(clock))
+
+	assert.Equal(5, allowed(g, 10))
+
+	clock.Add(200 * time.Millisecond)
+	assert.Equal(2, allowed(g, 10))
+
+	clock.Add(time.Hour)
+	assert.Equal(5, allowed(g, 10))
+
+	r := g.Reserve()
+	assert.ShouldBeTrue(r.OK())
+	assert.Equal(100*time.Millisecond, r.Delay())
+	r.Cancel()
+
+	clock.Add(100 * time.Millisecond)
+	assert.Equal(1, allowed(g, 10))
+
+	assert.ShouldBeFalse(NewGCRA(0, time.Second, 1).Allow())
+	assert.ShouldBeFalse(NewGCRA(0, time.Second, 1).Reserve().OK())
+	assert.Equal(ErrNeverAllowed, NewGCRA(0, time.Second, 1).Wait(context.Background()))
+
+	// more than one event per nanosecond
+	g = NewGCRA(2e9, time.Second, 1, WithClock(clock))
+	assert.Equal(2, allowed(g, 10))
+	clock.Add(time.Nanosecond)
+	assert.Equal(2, allowed(g, 10))
+	clock.Add(time.Millisecond)
+	assert.Equal(2, allowed(g, 10))
+
+	g = NewGCRA(2e9, 3*time.Second, 2000, WithClock(clock))
+	assert.Equal(2000, allowed(g, 5000))
+	clock.Add(3 * time.Nanosecond)
+	assert.Equal(2, allowed(g, 10))
+	r = g.Reserve()
+	r.Cancel()
+	assert.Equal(0, allowed(g, 10))
+}
+
+func TestSlidingWindowLog(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestSlidingWindowLog")
+
+	clock := newFakeClock()
+	l := NewSlidingWindowLog(3, time.Second, WithClock(clock))
+
+	assert.Equal(3, allowed(l, 10))
+
+	clock.Add(500 * time.Millisecond)
+	assert.Equal(0, allowed(l, 10))
+
+	// no burst at the window edge: the events leave the window one by one
+	clock.Add(500 * time.Millisecond)
+	assert.Equal(3, allowed(l, 10))
+
+	clock.Add(400 * time.Millisecond)
+	r1 := l.Reserve()
+	assert.Equal(600*time.Millisecond, r1.Delay())
+	r2 := l.Reserve()
+	assert.Equal(600*time.Millisecond, r2.Delay())
+	r3 := l.Reserve()
+	assert.Equal(600*time.Millisecond, r3.Delay())
+	r4 := l.Reserve()
+	assert.Equal(1600*time.Millisecond, r4.Delay())
+
+	// reserved events are not taken by Allow
+	clock.Add(700 * time.Millisecond)
+	assert.ShouldBeFalse(l.Allow())
+
+	r4.Cancel()
+	r3.Cancel()
+	assert.ShouldBeTrue(l.Allow())
+
+	assert.ShouldBeFalse(NewSlidingWindowLog(0, time.Second).Allow())
+	assert.ShouldBeFalse(NewSlidingWindowLog(0, time.Second).Reserve().OK())
+}
+
+func TestWait(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestWait")
+
+	for _, newLimiter := range []func(Clock) Limiter{
+		func(clock Clock) Limiter { return NewTokenBucket(1, time.Second, 1, WithClock(clock)) },
+		func(clock Clock) Limiter { return NewGCRA(1, time.Second, 1, WithClock(clock)) },
+		func(clock Clock) Limiter { return NewSlidingWindowLog(1, time.Second, WithClock(clock)) },
+	} {
+		clock := newFakeClock()
+		l := newLimiter(clock)
+
+		assert.IsNil(l.Wait(context.Background()))
+
+		done := make(chan error)
+		go func() {
+			done <- l.Wait(context.Background())
+		}()
+		for clock.pending() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Add(time.Second)
+		assert.IsNil(<-done)
+
+		// the deadline is before the event
+		ctx, cancel := context.WithDeadline(context.Background(), clock.Now().Add(time.Millisecond))
+		assert.Equal(ErrWaitExceedsDeadline, l.Wait(ctx))
+		cancel()
+
+		// canceled while waiting, the event is given back
+		ctx, cancel = context.WithCancel(context.Background())
+		go func() {
+			done <- l.Wait(ctx)
+		}()
+		for clock.pending() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		assert.Equal(context.Canceled, <-done)
+		clock.Add(time.Second)
+		assert.ShouldBeTrue(l.Allow())
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestKeyed")
+
+	clock := newFakeClock()
+	k := NewKeyed[string](func() Limiter {
+		return NewTokenBucket(1, time.Second, 2, WithClock(clock))
+	}, time.Minute, WithClock(clock))
+
+	assert.ShouldBeTrue(k.Allow("alice"))
+	assert.ShouldBeTrue(k.Allow("alice"))
+	assert.ShouldBeFalse(k.Allow("alice"))
+	assert.ShouldBeTrue(k.Allow("bob"))
+	assert.Equal(time.Duration(0), k.Reserve("bob").Delay())
+	assert.Equal(time.Second, k.Reserve("bob").Delay())
+	assert.Equal(2, k.Len())
+
+	clock.Add(30 * time.Second)
+	assert.ShouldBeTrue(k.Allow("alice"))
+	assert.Equal(0, k.Evict())
+
+	// bob is idle, the eviction happens while alice is used
+	clock.Add(40 * time.Second)
+	assert.ShouldBeTrue(k.Allow("alice"))
+	assert.Equal(1, k.Len())
+	assert.ShouldBeTrue(k.Limiter("alice") == k.Limiter("alice"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.IsNil(k.Wait(ctx, "carol"))
+
+	noEviction := NewKeyed[int](func() Limiter { return NewGCRA(1, time.Second, 1) }, 0)
+	noEviction.Allow(1)
+	assert.Equal(0, noEviction.Evict())
+	assert.Equal(1, noEviction.Len())
+}
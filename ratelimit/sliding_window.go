@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SlidingWindowLog is a limiter keeping the time of the recent events, it allows at most limit events
+// in any window of the given duration. Unlike the token bucket, it has no burst at the window edges,
+// but it stores up to limit times.
+type SlidingWindowLog struct {
+	clock  Clock
+	limit  int
+	window time.Duration
+
+	mu  sync.Mutex
+	log []time.Time
+}
+
+// NewSlidingWindowLog creates a sliding window log limiter allowing limit events per window.
+func NewSlidingWindowLog(limit int, window time.Duration, opts ...Option) *SlidingWindowLog {
+	c := newConfig(opts...)
+
+	if limit < 0 {
+		limit = 0
+	}
+
+	return &SlidingWindowLog{
+		clock:  c.clock,
+		limit:  limit,
+		window: window,
+		log:    make([]time.Time, 0, limit),
+	}
+}
+
+// Allow implements Limiter.
+func (l *SlidingWindowLog) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.prune(now)
+	if l.limit == 0 || l.next(now).After(now) {
+		return false
+	}
+	l.log = append(l.log, now)
+	return true
+}
+
+// Wait implements Limiter.
+func (l *SlidingWindowLog) Wait(ctx context.Context) error {
+	return wait(ctx, l.Reserve())
+}
+
+// Reserve implements Limiter.
+func (l *SlidingWindowLog) Reserve() *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	r := &Reservation{clock: l.clock, timeToAct: now}
+	if l.limit == 0 {
+		return r
+	}
+
+	l.prune(now)
+	r.ok = true
+	r.timeToAct = l.next(now)
+	l.log = append(l.log, r.timeToAct)
+
+	at := r.timeToAct
+	r.cancel = func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		for i := len(l.log) - 1; i >= 0; i-- {
+			if l.log[i].Equal(at) {
+				l.log = append(l.log[:i], l.log[i+1:]...)
+				return
+			}
+		}
+	}
+	return r
+}
+
+// next returns the earliest time of a new event. The events are allowed in order, so that
+// the log stays sorted: a new event comes after the reserved ones, and once the limit-th
+// latest event left the window.
+func (l *SlidingWindowLog) next(now time.Time) time.Time {
+	at := now
+	if n := len(l.log); n > 0 && l.log[n-1].After(at) {
+		at = l.log[n-1]
+	}
+	if n := len(l.log); n >= l.limit {
+		if slot := l.log[n-l.limit].Add(l.window); slot.After(at) {
+			at = slot
+		}
+	}
+	return at
+}
+
+// prune removes the events which left the window.
+func (l *SlidingWindowLog) prune(now time.Time) {
+	start := now.Add(-l.window)
+	i := 0
+	for i < len(l.log) && !l.log[i].After(start) {
+		i++
+	}
+	if i > 0 {
+		l.log = append(l.log[:0], l.log[i:]...)
+	}
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a limiter refilling a bucket of tokens at a constant rate, each event consumes a token.
+// It allows bursts up to the size of the bucket.
+type TokenBucket struct {
+	clock Clock
+
+	// rate is the number of tokens added per second.
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a token bucket refilled with limit tokens per period, holding up to burst tokens.
+// The bucket starts full.
+func NewTokenBucket(limit int, period time.Duration, burst int, opts ...Option) *TokenBucket {
+	c := newConfig(opts...)
+
+	rate := 0.0
+	if limit > 0 && period > 0 {
+		rate = float64(limit) / period.Seconds()
+	}
+	if burst < 0 {
+		burst = 0
+	}
+
+	return &TokenBucket{
+		clock:  c.clock,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   c.clock.Now(),
+	}
+}
+
+// Allow implements Limiter.
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(b.clock.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait implements Limiter.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b.Reserve())
+}
+
+// Reserve implements Limiter.
+func (b *TokenBucket) Reserve() *Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	b.advance(now)
+
+	r := &Reservation{clock: b.clock, timeToAct: now}
+	if b.tokens < 1 && (b.rate == 0 || b.burst < 1) {
+		return r
+	}
+
+	r.ok = true
+	b.tokens--
+	if b.tokens < 0 {
+		r.timeToAct = now.Add(time.Duration(-b.tokens / b.rate * float64(time.Second)))
+	}
+	r.cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.advance(b.clock.Now())
+		b.tokens++
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	return r
+}
+
+// Tokens returns the number of tokens in the bucket, negative when events are reserved ahead.
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(b.clock.Now())
+	return b.tokens
+}
+
+// advance refills the bucket up to now.
+func (b *TokenBucket) advance(now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+	b.last = now
+
+	b.tokens += elapsed.Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
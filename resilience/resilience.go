@@ -0,0 +1,279 @@
+// Package resilience composes retries, timeouts, bulkheads, hedged requests, circuit breakers
+// and fallbacks into a single policy.
+//
+// A pipeline applies its policies from the outermost to the innermost, so the order matters.
+// A common order is:
+//
+//	Pipeline(
+//		Fallback(...),      // replaces the final error
+//		Retry(...),         // retries the whole attempt below
+//		Timeout(total),     // bounds each attempt, queueing included
+//		Isolate(bulkhead),  // bounds the concurrent attempts
+//		Breaker(b),         // short-circuits when the dependency is down
+//		Hedge(delay, n),    // races slow attempts
+//		Timeout(perCall),   // bounds each call
+//	)
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sllt/af/breaker"
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/retry"
+)
+
+var (
+	// ErrTimeout is returned when a Timeout policy expires, it wraps context.DeadlineExceeded.
+	ErrTimeout = fmt.Errorf("resilience: timeout: %w", context.DeadlineExceeded)
+
+	// ErrBulkheadFull is returned when the bulkhead has no room left in its queue.
+	ErrBulkheadFull = errors.New("resilience: bulkhead is full")
+)
+
+// Func is the function run by a policy.
+type Func[T any] func(ctx context.Context) (T, error)
+
+// Policy wraps the execution of a function.
+type Policy[T any] interface {
+	Execute(ctx context.Context, fn Func[T]) (T, error)
+}
+
+// PolicyFunc is an adapter to use an ordinary function as a Policy.
+type PolicyFunc[T any] func(ctx context.Context, fn Func[T]) (T, error)
+
+// Execute implements Policy.
+func (p PolicyFunc[T]) Execute(ctx context.Context, fn Func[T]) (T, error) {
+	return p(ctx, fn)
+}
+
+// Pipeline composes policies, the first one is the outermost.
+func Pipeline[T any](policies ...Policy[T]) Policy[T] {
+	return PolicyFunc[T](func(ctx context.Context, fn Func[T]) (T, error) {
+		for i := len(policies) - 1; i >= 0; i-- {
+			policy, next := policies[i], fn
+			fn = func(ctx context.Context) (T, error) {
+				return policy.Execute(ctx, next)
+			}
+		}
+		return fn(ctx)
+	})
+}
+
+// Retry retries the function with retry.Do, it accepts the same options.
+func Retry[T any](opts ...retry.Option) Policy[T] {
+	return PolicyFunc[T](func(ctx context.Context, fn Func[T]) (T, error) {
+		return retry.Do(ctx, func(ctx context.Context, _ int) (T, error) {
+			return fn(ctx)
+		}, opts...)
+	})
+}
+
+// Timeout bounds the execution of the function. It returns ErrTimeout once the timeout expires,
+// even when the function ignores its context, which keeps running in the background. A failure of
+// the function once the timeout expired is reported as ErrTimeout too, or as the error of ctx
+// when ctx is done.
+func Timeout[T any](timeout time.Duration) Policy[T] {
+	return PolicyFunc[T](func(ctx context.Context, fn Func[T]) (T, error) {
+		timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		type result struct {
+			value T
+			err   error
+		}
+		done := make(chan result, 1)
+		go func() {
+			value, err := fn(timeoutCtx)
+			done <- result{value: value, err: err}
+		}()
+
+		// timeoutErr is the error once timeoutCtx is done, whether fn returned or not
+		timeoutErr := func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return ErrTimeout
+		}
+
+		select {
+		case r := <-done:
+			if r.err != nil && timeoutCtx.Err() != nil {
+				return r.value, timeoutErr()
+			}
+			return r.value, r.err
+		case <-timeoutCtx.Done():
+			var zero T
+			return zero, timeoutErr()
+		}
+	})
+}
+
+// Breaker runs the function through a circuit breaker.
+func Breaker[T any](b *breaker.Breaker) Policy[T] {
+	return PolicyFunc[T](func(ctx context.Context, fn Func[T]) (T, error) {
+		return breaker.Execute(b, func() (T, error) {
+			return fn(ctx)
+		})
+	})
+}
+
+// Fallback replaces the error of the function with the result of fallback.
+func Fallback[T any](fallback func(ctx context.Context, err error) (T, error)) Policy[T] {
+	return PolicyFunc[T](func(ctx context.Context, fn Func[T]) (T, error) {
+		value, err := fn(ctx)
+		if err != nil {
+			return fallback(ctx, err)
+		}
+		return value, nil
+	})
+}
+
+// Hedge starts another attempt of the function each time delay passes without a result, or when
+// an attempt fails, up to maxAttempts attempts in total. The first success is returned and the
+// other attempts are canceled. When every attempt fails, the errors are joined.
+func Hedge[T any](delay time.Duration, maxAttempts int) Policy[T] {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return PolicyFunc[T](func(ctx context.Context, fn Func[T]) (T, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			value T
+			err   error
+		}
+		results := make(chan result, maxAttempts)
+		start := func() {
+			go func() {
+				value, err := fn(ctx)
+				results <- result{value: value, err: err}
+			}()
+		}
+
+		start()
+		started := 1
+		var errs []error
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		for {
+			select {
+			case r := <-results:
+				if r.err == nil {
+					return r.value, nil
+				}
+				errs = append(errs, r.err)
+				if len(errs) == maxAttempts {
+					var zero T
+					return zero, internal.JoinError(errs...)
+				}
+				// an attempt failed fast, start the next one at once
+				if started < maxAttempts {
+					start()
+					started++
+				}
+			case <-timer.C:
+				if started < maxAttempts {
+					start()
+					started++
+					timer.Reset(delay)
+				}
+			case <-ctx.Done():
+				var zero T
+				return zero, ctx.Err()
+			}
+		}
+	})
+}
+
+// Bulkhead bounds the number of concurrent executions, with a bounded queue of waiting executions.
+// It can be shared by several policies, to isolate a dependency.
+type Bulkhead struct {
+	slots chan struct{}
+
+	mu       sync.Mutex
+	queued   int
+	maxQueue int
+}
+
+// NewBulkhead creates a bulkhead running up to maxConcurrent executions, and queueing up to maxQueue.
+func NewBulkhead(maxConcurrent, maxQueue int) *Bulkhead {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+
+	return &Bulkhead{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxQueue: maxQueue,
+	}
+}
+
+// Running returns the number of running executions.
+func (b *Bulkhead) Running() int {
+	return len(b.slots)
+}
+
+// Queued returns the number of waiting executions.
+func (b *Bulkhead) Queued() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queued
+}
+
+// acquire gets a slot, waiting in the queue if needed.
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	b.mu.Lock()
+	if b.queued >= b.maxQueue {
+		b.mu.Unlock()
+		return ErrBulkheadFull
+	}
+	b.queued++
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		b.queued--
+		b.mu.Unlock()
+	}()
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bulkhead) release() {
+	<-b.slots
+}
+
+// Isolate runs the function in the bulkhead, it returns ErrBulkheadFull when the queue is full.
+func Isolate[T any](b *Bulkhead) Policy[T] {
+	return PolicyFunc[T](func(ctx context.Context, fn Func[T]) (T, error) {
+		if err := b.acquire(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+		defer b.release()
+
+		return fn(ctx)
+	})
+}
@@ -0,0 +1,223 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/breaker"
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/retry"
+)
+
+var errFailed = errors.New("failed")
+
+func TestPipelineOrder(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestPipelineOrder")
+
+	trace := []string{}
+	named := func(name string) Policy[int] {
+		return PolicyFunc[int](func(ctx context.Context, fn Func[int]) (int, error) {
+			trace = append(trace, name+" in")
+			value, err := fn(ctx)
+			trace = append(trace, name+" out")
+			return value, err
+		})
+	}
+
+	value, err := Pipeline(named("a"), named("b")).Execute(context.Background(), func(ctx context.Context) (int, error) {
+		trace = append(trace, "fn")
+		return 42, nil
+	})
+
+	assert.IsNil(err)
+	assert.Equal(42, value)
+	assert.Equal([]string{"a in", "b in", "fn", "b out", "a out"}, trace)
+
+	value, err = Pipeline[int]().Execute(context.Background(), func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	assert.IsNil(err)
+	assert.Equal(1, value)
+}
+
+func TestRetryAndTimeout(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestRetryAndTimeout")
+
+	// each attempt has its own timeout
+	var attempts int32
+	policy := Pipeline(
+		Retry[string](retry.RetryTimes(3), retry.RetryWithLinearBackoff(time.Millisecond)),
+		Timeout[string](10*time.Millisecond),
+	)
+
+	value, err := policy.Execute(context.Background(), func(ctx context.Context) (string, error) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "ok", nil
+	})
+
+	assert.IsNil(err)
+	assert.Equal("ok", value)
+	assert.Equal(int32(3), atomic.LoadInt32(&attempts))
+
+	// the timeout returns even if the function ignores its context
+	_, err = Timeout[int](10*time.Millisecond).Execute(context.Background(), func(ctx context.Context) (int, error) {
+		time.Sleep(50 * time.Millisecond)
+		return 0, nil
+	})
+	assert.Equal(ErrTimeout, err)
+	assert.ShouldBeTrue(errors.Is(err, context.DeadlineExceeded))
+
+	// the context error of a function returning as the timeout expires is the timeout too
+	for i := 0; i < 20; i++ {
+		_, err = Timeout[int](time.Millisecond).Execute(context.Background(), func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		assert.Equal(ErrTimeout, err)
+	}
+
+	// the parent context error is kept
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Timeout[int](time.Second).Execute(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return 0, nil
+	})
+	assert.Equal(context.Canceled, err)
+}
+
+func TestBulkhead(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestBulkhead")
+
+	b := NewBulkhead(1, 1)
+	policy := Isolate[int](b)
+
+	release := make(chan struct{})
+	blocked := func(ctx context.Context) (int, error) {
+		<-release
+		return 1, nil
+	}
+
+	done := make(chan error, 2)
+	go func() {
+		_, err := policy.Execute(context.Background(), blocked)
+		done <- err
+	}()
+	for b.Running() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		_, err := policy.Execute(context.Background(), blocked)
+		done <- err
+	}()
+	for b.Queued() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err := policy.Execute(context.Background(), blocked)
+	assert.Equal(ErrBulkheadFull, err)
+
+	close(release)
+	assert.IsNil(<-done)
+	assert.IsNil(<-done)
+	assert.Equal(0, b.Running())
+	assert.Equal(0, b.Queued())
+
+	// waiting in the queue honors the context
+	hold := make(chan struct{})
+	go func() {
+		_, _ = policy.Execute(context.Background(), func(ctx context.Context) (int, error) {
+			<-hold
+			return 0, nil
+		})
+	}()
+	for b.Running() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = policy.Execute(ctx, blocked)
+	assert.Equal(context.DeadlineExceeded, err)
+	close(hold)
+}
+
+func TestHedge(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestHedge")
+
+	var attempts, canceled int32
+	value, err := Hedge[int](10*time.Millisecond, 3).Execute(context.Background(), func(ctx context.Context) (int, error) {
+		n := atomic.AddInt32(&attempts, 1)
+		if n == 1 {
+			// the first attempt is slow
+			select {
+			case <-ctx.Done():
+				atomic.AddInt32(&canceled, 1)
+				return 0, ctx.Err()
+			case <-time.After(time.Second):
+				return 1, nil
+			}
+		}
+		return int(n), nil
+	})
+
+	assert.IsNil(err)
+	assert.Equal(2, value)
+	assert.Equal(int32(2), atomic.LoadInt32(&attempts))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(int32(1), atomic.LoadInt32(&canceled))
+
+	// every attempt fails
+	attempts = 0
+	_, err = Hedge[int](time.Hour, 3).Execute(context.Background(), func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&attempts, 1)
+		return 0, errFailed
+	})
+	assert.ShouldBeTrue(errors.Is(err, errFailed))
+	assert.Equal(int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestBreakerAndFallback(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestBreakerAndFallback")
+
+	b := breaker.New(breaker.Options{ConsecutiveFailures: 1, Cooldown: time.Hour})
+	var calls int32
+	policy := Pipeline(
+		Fallback(func(ctx context.Context, err error) (string, error) {
+			if errors.Is(err, breaker.ErrOpenState) {
+				return "cached", nil
+			}
+			return "", err
+		}),
+		Breaker[string](b),
+	)
+
+	fn := func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "", errFailed
+	}
+
+	_, err := policy.Execute(context.Background(), fn)
+	assert.Equal(errFailed, err)
+
+	value, err := policy.Execute(context.Background(), fn)
+	assert.IsNil(err)
+	assert.Equal("cached", value)
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,129 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Attempt describes a failed attempt, it is passed to the OnRetry and OnGiveUp hooks.
+type Attempt struct {
+	// Number is the number of the attempt, starting at 1.
+	Number int
+	// Err is the error returned by the attempt.
+	Err error
+	// Elapsed is the time elapsed since the first attempt started.
+	Elapsed time.Duration
+	// NextDelay is the delay before the next attempt, it is 0 when giving up.
+	NextDelay time.Duration
+}
+
+// OnRetry set the hook called after each failed attempt which is going to be retried.
+func OnRetry(onRetry func(attempt Attempt)) Option {
+	return func(rc *RetryConfig) {
+		rc.onRetry = onRetry
+	}
+}
+
+// OnGiveUp set the hook called when Do stops retrying after a failed attempt.
+func OnGiveUp(onGiveUp func(attempt Attempt)) Option {
+	return func(rc *RetryConfig) {
+		rc.onGiveUp = onGiveUp
+	}
+}
+
+// RetryError is returned by Do when every attempt failed, it holds the error of each attempt.
+// errors.Is and errors.As look through all of them, the last cause included.
+type RetryError struct {
+	Errors []error
+}
+
+// Error returns the number of attempts and the last error.
+func (e *RetryError) Error() string {
+	if len(e.Errors) == 0 {
+		return "retry: no attempt"
+	}
+
+	return fmt.Sprintf("retry: %d attempts failed, last error: %v", len(e.Errors), e.Last())
+}
+
+// Unwrap returns the errors of the attempts.
+func (e *RetryError) Unwrap() []error {
+	return e.Errors
+}
+
+// Last returns the error of the last attempt.
+func (e *RetryError) Last() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors[len(e.Errors)-1]
+}
+
+// Do executes fn repeatedly until it was successful, and returns its result.
+// fn receives ctx and the number of the attempt, starting at 1.
+//
+// Do accepts the same options as Retry, the Context option excepted since ctx is used instead.
+// Unlike Retry, it does not wait after the last attempt. On failure, it returns a *RetryError
+// holding the error of every attempt, followed by ctx.Err() when ctx is done. Like Retry, it
+// makes no attempt with RetryTimes(0), and returns a *RetryError without errors.
+func Do[T any](ctx context.Context, fn func(ctx context.Context, attempt int) (T, error), opts ...Option) (T, error) {
+	config := newRetryConfig(opts...)
+
+	var zero T
+	var errs []error
+	start := time.Now()
+
+	giveUp := func(number int, err error) (T, error) {
+		if config.onGiveUp != nil {
+			config.onGiveUp(Attempt{Number: number, Err: err, Elapsed: time.Since(start)})
+		}
+		return zero, &RetryError{Errors: errs}
+	}
+
+	if config.retryTimes == 0 {
+		return zero, &RetryError{}
+	}
+
+	for number := 1; ; number++ {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			return giveUp(number-1, err)
+		}
+
+		var value T
+		err := config.call(func() (err error) {
+			value, err = fn(ctx, number)
+			return err
+		})
+		if err == nil {
+			return value, nil
+		}
+
+		err, permanent := unwrapPermanent(err)
+		errs = append(errs, err)
+
+		if permanent || uint(number) >= config.retryTimes ||
+			(config.retryIf != nil && !config.retryIf(err)) {
+			return giveUp(number, err)
+		}
+
+		delay, ok := retryAfter(err)
+		if !ok {
+			delay = config.backoffStrategy.CalculateInterval()
+		}
+
+		if config.onRetry != nil {
+			config.onRetry(Attempt{Number: number, Err: err, Elapsed: time.Since(start), NextDelay: delay})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			errs = append(errs, ctx.Err())
+			return giveUp(number, err)
+		}
+	}
+}
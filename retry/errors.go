@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"errors"
+	"time"
+)
+
+// PermanentError is an error which must not be retried, Retry returns the wrapped error at once,
+// even when the PermanentError is itself wrapped by the returned error.
+type PermanentError struct {
+	Err error
+}
+
+// Error returns the message of the wrapped error.
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err to stop retrying, it returns nil when err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err, or an error wrapped by err, is permanent.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// unwrapPermanent returns the error wrapped by the PermanentError found in err, and whether there
+// is one. Otherwise it returns err.
+func unwrapPermanent(err error) (error, bool) {
+	var permanent *PermanentError
+	if errors.As(err, &permanent) {
+		return permanent.Err, true
+	}
+	return err, false
+}
+
+// RetryAfterError is an error dictating the delay before the next retry,
+// for example from the Retry-After header of an HTTP response.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// Error returns the message of the wrapped error.
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the delay before the next retry.
+func (e *RetryAfterError) RetryAfter() time.Duration {
+	return e.Delay
+}
+
+// RetryAfter wraps err to override the backoff strategy for the next retry, it returns nil when err is nil.
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryAfterError{Err: err, Delay: delay}
+}
+
+// retryAfter returns the delay dictated by err, or an error wrapped by err.
+// Any error having a `RetryAfter() time.Duration` method can dictate the delay.
+func retryAfter(err error) (time.Duration, bool) {
+	var e interface{ RetryAfter() time.Duration }
+	if errors.As(err, &e) {
+		return e.RetryAfter(), true
+	}
+	return 0, false
+}
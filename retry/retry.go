@@ -3,7 +3,6 @@ package retry
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -11,6 +10,8 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/sllt/af/breaker"
 )
 
 const (
@@ -25,6 +26,10 @@ type RetryConfig struct {
 	context         context.Context
 	retryTimes      uint
 	backoffStrategy BackoffStrategy
+	retryIf         func(error) bool
+	onRetry         func(Attempt)
+	onGiveUp        func(Attempt)
+	breaker         *breaker.Breaker
 }
 
 // RetryFunc is function that retry executes
@@ -33,7 +38,35 @@ type RetryFunc func() error
 // Option is for adding retry config
 type Option func(*RetryConfig)
 
+func newRetryConfig(opts ...Option) *RetryConfig {
+	config := &RetryConfig{
+		retryTimes: DefaultRetryTimes,
+		context:    context.TODO(),
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.backoffStrategy == nil {
+		config.backoffStrategy = &linear{
+			interval: DefaultRetryLinearInterval,
+		}
+	}
+
+	return config
+}
+
+// call runs an attempt, through the circuit breaker if any.
+func (rc *RetryConfig) call(fn func() error) error {
+	if rc.breaker == nil {
+		return fn()
+	}
+	return rc.breaker.Execute(fn)
+}
+
 // RetryTimes set times of retry.
+// It is the maximum number of calls of the function by Retry and Do, none when n is 0.
 func RetryTimes(n uint) Option {
 	return func(rc *RetryConfig) {
 		rc.retryTimes = n
@@ -93,6 +126,31 @@ func RetryWithExponentialWithJitterBackoff(interval time.Duration, base uint64,
 	}
 }
 
+// RetryIf set the predicate deciding whether an error is retried.
+// When it returns false, Retry stops and returns the error.
+func RetryIf(retryIf func(err error) bool) Option {
+	if retryIf == nil {
+		panic("programming error: retryIf must be not nil")
+	}
+
+	return func(rc *RetryConfig) {
+		rc.retryIf = retryIf
+	}
+}
+
+// RetryWithBreaker set a circuit breaker guarding the attempts.
+// While the breaker is open, the attempts fail at once with breaker.ErrOpenState
+// without calling the function, and the backoff strategy goes on.
+func RetryWithBreaker(b *breaker.Breaker) Option {
+	if b == nil {
+		panic("programming error: breaker must be not nil")
+	}
+
+	return func(rc *RetryConfig) {
+		rc.breaker = b
+	}
+}
+
 // Context set retry context config.
 func Context(ctx context.Context) Option {
 	return func(rc *RetryConfig) {
@@ -102,30 +160,34 @@ func Context(ctx context.Context) Option {
 
 // Retry executes the retryFunc repeatedly until it was successful or canceled by the context
 // The default times of retries is 5 and the default duration between retries is 3 seconds.
+// When the context is done, the returned error wraps the error of the context.
+//
+// Retry stops at once and returns the error when it is wrapped by Permanent, or when it is rejected
+// by the RetryIf predicate. An error wrapped by RetryAfter dictates the delay before the next retry
+// instead of the backoff strategy.
 func Retry(retryFunc RetryFunc, opts ...Option) error {
-	config := &RetryConfig{
-		retryTimes: DefaultRetryTimes,
-		context:    context.TODO(),
-	}
-
-	for _, opt := range opts {
-		opt(config)
-	}
-
-	if config.backoffStrategy == nil {
-		config.backoffStrategy = &linear{
-			interval: DefaultRetryLinearInterval,
-		}
-	}
+	config := newRetryConfig(opts...)
 
 	var i uint
 	for i < config.retryTimes {
-		err := retryFunc()
+		err := config.call(retryFunc)
 		if err != nil {
+			if err, ok := unwrapPermanent(err); ok {
+				return err
+			}
+			if config.retryIf != nil && !config.retryIf(err) {
+				return err
+			}
+
+			interval, ok := retryAfter(err)
+			if !ok {
+				interval = config.backoffStrategy.CalculateInterval()
+			}
+
 			select {
-			case <-time.After(config.backoffStrategy.CalculateInterval()):
+			case <-time.After(interval):
 			case <-config.context.Done():
-				return errors.New("retry is cancelled")
+				return fmt.Errorf("retry is cancelled: %w", config.context.Err())
 			}
 		} else {
 			return nil
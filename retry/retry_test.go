@@ -3,9 +3,11 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/sllt/af/breaker"
 	"github.com/sllt/af/internal"
 )
 
@@ -233,6 +235,34 @@ func TestSetRetryTimes(t *testing.T) {
 	assert.Equal(3, number)
 }
 
+func TestRetryTimesZero(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestRetryTimesZero")
+
+	var number int
+	increaseNumber := func() error {
+		number++
+		return nil
+	}
+
+	err := Retry(increaseNumber, RetryTimes(0))
+	assert.IsNotNil(err)
+	assert.Equal(0, number)
+
+	// Do makes no attempt either
+	_, err = Do(context.Background(), func(ctx context.Context, attempt int) (int, error) {
+		number++
+		return 0, nil
+	}, RetryTimes(0))
+
+	var retryErr *RetryError
+	assert.ShouldBeTrue(errors.As(err, &retryErr))
+	assert.Equal(0, len(retryErr.Errors))
+	assert.Equal("retry: no attempt", err.Error())
+	assert.Equal(0, number)
+}
+
 func TestCancelRetry(t *testing.T) {
 	t.Parallel()
 
@@ -255,4 +285,248 @@ func TestCancelRetry(t *testing.T) {
 
 	assert.IsNotNil(err)
 	assert.Equal(4, number)
+	assert.ShouldBeTrue(errors.Is(err, context.Canceled))
+
+	ctx, cancel = context.WithTimeout(context.TODO(), time.Millisecond)
+	defer cancel()
+	err = Retry(func() error { return errors.New("error occurs") },
+		RetryWithLinearBackoff(time.Second),
+		Context(ctx),
+	)
+	assert.ShouldBeTrue(errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestRetryPermanent(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestRetryPermanent")
+
+	errFatal := errors.New("fatal")
+	var number int
+	err := Retry(func() error {
+		number++
+		return Permanent(errFatal)
+	}, RetryWithLinearBackoff(time.Microsecond*50))
+
+	assert.Equal(errFatal, err)
+	assert.Equal(1, number)
+	assert.IsNil(Permanent(nil))
+
+	wrapped := fmt.Errorf("wrapped: %w", Permanent(errFatal))
+	number = 0
+	err = Retry(func() error {
+		number++
+		return wrapped
+	}, RetryWithLinearBackoff(time.Microsecond*50))
+
+	// a wrapped permanent error is unwrapped too
+	assert.Equal(errFatal, err)
+	assert.Equal(1, number)
+	assert.ShouldBeFalse(IsPermanent(err))
+	assert.ShouldBeTrue(IsPermanent(wrapped))
+}
+
+func TestRetryIf(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestRetryIf")
+
+	errTemporary := errors.New("temporary")
+	errFatal := errors.New("fatal")
+
+	var number int
+	err := Retry(func() error {
+		number++
+		if number < 3 {
+			return errTemporary
+		}
+		return errFatal
+	},
+		RetryWithLinearBackoff(time.Microsecond*50),
+		RetryIf(func(err error) bool {
+			return errors.Is(err, errTemporary)
+		}),
+	)
+
+	assert.Equal(errFatal, err)
+	assert.Equal(3, number)
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestRetryAfter")
+
+	var number int
+	start := time.Now()
+	err := Retry(func() error {
+		number++
+		if number == 1 {
+			return RetryAfter(errors.New("throttled"), 20*time.Millisecond)
+		}
+		return nil
+	}, RetryWithLinearBackoff(time.Hour))
+
+	assert.IsNil(err)
+	assert.Equal(2, number)
+	assert.ShouldBeTrue(time.Since(start) >= 20*time.Millisecond)
+	assert.IsNil(RetryAfter(nil, time.Second))
+
+	delay, ok := retryAfter(fmt.Errorf("wrapped: %w", RetryAfter(errors.New("throttled"), time.Second)))
+	assert.ShouldBeTrue(ok)
+	assert.Equal(time.Second, delay)
+
+	// cancellation while waiting for the dictated delay
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = Retry(func() error {
+		return RetryAfter(errors.New("throttled"), time.Hour)
+	}, Context(ctx))
+
+	assert.IsNotNil(err)
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestDo")
+
+	attempts := []Attempt{}
+	value, err := Do(context.Background(), func(ctx context.Context, attempt int) (int, error) {
+		if attempt < 3 {
+			return 0, errors.New("error occurs")
+		}
+		return attempt * 10, nil
+	},
+		RetryWithLinearBackoff(time.Microsecond*50),
+		OnRetry(func(attempt Attempt) {
+			attempts = append(attempts, attempt)
+		}),
+	)
+
+	assert.IsNil(err)
+	assert.Equal(30, value)
+	assert.Equal(2, len(attempts))
+	assert.Equal(1, attempts[0].Number)
+	assert.Equal(2, attempts[1].Number)
+	assert.Equal(time.Microsecond*50, attempts[1].NextDelay)
+	assert.ShouldBeTrue(attempts[1].Elapsed >= attempts[0].Elapsed)
+}
+
+func TestDoFailed(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestDoFailed")
+
+	errLast := errors.New("last error")
+	var giveUp *Attempt
+	var retries int
+	_, err := Do(context.Background(), func(ctx context.Context, attempt int) (string, error) {
+		if attempt == 3 {
+			return "", fmt.Errorf("attempt %d: %w", attempt, errLast)
+		}
+		return "", fmt.Errorf("attempt %d", attempt)
+	},
+		RetryTimes(3),
+		RetryWithLinearBackoff(time.Microsecond*50),
+		OnRetry(func(attempt Attempt) { retries++ }),
+		OnGiveUp(func(attempt Attempt) { giveUp = &attempt }),
+	)
+
+	var retryErr *RetryError
+	assert.ShouldBeTrue(errors.As(err, &retryErr))
+	assert.Equal(3, len(retryErr.Errors))
+	assert.ShouldBeTrue(errors.Is(err, errLast))
+	assert.Equal("attempt 3: last error", retryErr.Last().Error())
+	assert.Equal("retry: 3 attempts failed, last error: attempt 3: last error", err.Error())
+	assert.Equal(2, retries)
+	assert.IsNotNil(giveUp)
+	assert.Equal(3, giveUp.Number)
+	assert.Equal(time.Duration(0), giveUp.NextDelay)
+}
+
+func TestDoPermanent(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestDoPermanent")
+
+	errFatal := errors.New("fatal")
+	var number int
+	_, err := Do(context.Background(), func(ctx context.Context, attempt int) (int, error) {
+		number = attempt
+		return 0, Permanent(errFatal)
+	}, RetryWithLinearBackoff(time.Microsecond*50))
+
+	assert.Equal(1, number)
+	assert.ShouldBeTrue(errors.Is(err, errFatal))
+	assert.ShouldBeFalse(IsPermanent(err))
+
+	var retryErr *RetryError
+	assert.ShouldBeTrue(errors.As(err, &retryErr))
+	assert.Equal([]error{errFatal}, retryErr.Errors)
+
+	// a wrapped permanent error is unwrapped too
+	number = 0
+	_, err = Do(context.Background(), func(ctx context.Context, attempt int) (int, error) {
+		number = attempt
+		return 0, fmt.Errorf("wrapped: %w", Permanent(errFatal))
+	}, RetryWithLinearBackoff(time.Microsecond*50))
+
+	assert.Equal(1, number)
+	assert.ShouldBeFalse(IsPermanent(err))
+	assert.ShouldBeTrue(errors.As(err, &retryErr))
+	assert.Equal([]error{errFatal}, retryErr.Errors)
+}
+
+func TestDoCancel(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestDoCancel")
+
+	errTemporary := errors.New("temporary")
+	ctx, cancel := context.WithCancel(context.Background())
+	var number int
+	_, err := Do(ctx, func(ctx context.Context, attempt int) (int, error) {
+		number = attempt
+		if attempt == 2 {
+			cancel()
+		}
+		return 0, errTemporary
+	}, RetryWithLinearBackoff(time.Microsecond*50))
+
+	assert.Equal(2, number)
+	assert.ShouldBeTrue(errors.Is(err, context.Canceled))
+	assert.ShouldBeTrue(errors.Is(err, errTemporary))
+
+	_, err = Do(ctx, func(ctx context.Context, attempt int) (int, error) {
+		return 0, nil
+	})
+	assert.ShouldBeTrue(errors.Is(err, context.Canceled))
+}
+
+func TestRetryWithBreaker(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestRetryWithBreaker")
+
+	b := breaker.New(breaker.Options{ConsecutiveFailures: 2, Cooldown: time.Hour})
+
+	var number int
+	err := Retry(func() error {
+		number++
+		return errors.New("error occurs")
+	}, RetryWithLinearBackoff(time.Microsecond*50), RetryWithBreaker(b))
+
+	assert.IsNotNil(err)
+	assert.Equal(2, number)
+	assert.Equal(breaker.StateOpen, b.State())
+
+	number = 0
+	_, err = Do(context.Background(), func(ctx context.Context, attempt int) (int, error) {
+		number++
+		return 0, nil
+	}, RetryTimes(2), RetryWithLinearBackoff(time.Microsecond*50), RetryWithBreaker(b))
+
+	assert.ShouldBeTrue(errors.Is(err, breaker.ErrOpenState))
+	assert.Equal(0, number)
 }
@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/sllt/af/iterator"
+)
+
+// FromIterator creates stream from iterator. The iterator is consumed as the elements are consumed,
+// the elements read are kept so that the stream can be consumed several times.
+func FromIterator[T any](iter iterator.Iterator[T]) Stream[T] {
+	return memoize(iter.Next)
+}
+
+// Iterator returns an iterator over the elements of the stream. Stop must be called when the
+// iterator is not consumed until its end, to release the sources of the stream, such as a
+// sequence given to FromSeq.
+func (s Stream[T]) Iterator() iterator.StopIterator[T] {
+	next, stop := s.iterator()
+	return &stopIterator[T]{Iterator: iterator.FromFunc(next), stop: stop}
+}
+
+type stopIterator[T any] struct {
+	iterator.Iterator[T]
+	stop func()
+}
+
+// Stop implements iterator.StopIterator.
+func (iter *stopIterator[T]) Stop() {
+	iter.stop()
+}
+
+// All returns a sequence of the elements of the stream, with the signature of iter.Seq:
+// with Go 1.23, the elements can be ranged over with `for item := range s.All()`.
+func (s Stream[T]) All() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		s.each(yield)
+	}
+}
+
+// ToChannel creates a new goroutine sending the elements of the stream to the returned channel,
+// which is closed once the stream is consumed or ctx is done.
+func (s Stream[T]) ToChannel(ctx context.Context, buffer int) <-chan T {
+	result := make(chan T, buffer)
+
+	go func() {
+		defer close(result)
+
+		s.each(func(item T) bool {
+			select {
+			case result <- item:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return result
+}
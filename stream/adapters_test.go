@@ -0,0 +1,69 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/iterator"
+)
+
+func TestFromIterator(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestFromIterator")
+
+	s := FromIterator[int](iterator.FromRange(0, 5, 1))
+	assert.Equal([]int{0, 1}, s.Limit(2).ToSlice())
+	// the elements read are kept
+	assert.Equal([]int{0, 1, 2, 3, 4}, s.ToSlice())
+	assert.Equal(5, s.Count())
+}
+
+func TestStream_Iterator(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestStream_Iterator")
+
+	iter := Of(1, 2, 3, 4).Filter(func(n int) bool { return n%2 == 0 }).Iterator()
+	defer iter.Stop()
+	assert.Equal(true, iter.HasNext())
+	assert.Equal([]int{2, 4}, iterator.ToSlice[int](iter))
+	assert.Equal(false, iter.HasNext())
+}
+
+func TestStream_All(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestStream_All")
+
+	result := []int{}
+	Of(1, 2, 3, 4).All()(func(n int) bool {
+		result = append(result, n)
+		return n < 2
+	})
+	assert.Equal([]int{1, 2}, result)
+}
+
+func TestStream_ToChannel(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestStream_ToChannel")
+
+	ch := Of(1, 2, 3).ToChannel(context.Background(), 0)
+	assert.Equal([]int{1, 2, 3}, FromChannel(ch).ToSlice())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	naturals := Generate(func() func() (int, bool) {
+		n := 0
+		return func() (int, bool) {
+			n++
+			return n, true
+		}
+	})
+	ch = naturals.ToChannel(ctx, 0)
+	assert.Equal(1, <-ch)
+	cancel()
+	for range ch {
+	}
+}
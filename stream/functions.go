@@ -0,0 +1,172 @@
+package stream
+
+import (
+	set "github.com/sllt/af/datastructure/set"
+	"github.com/sllt/af/tuple"
+)
+
+// The operations changing the type of the elements are functions, since methods can't have
+// type parameters. Like the methods, the functions returning a stream are lazy.
+
+// Map returns a stream consisting of the results of applying the given function to the elements of the stream.
+func Map[T, U any](s Stream[T], mapper func(item T) U) Stream[U] {
+	return fromStoppable(func() (func() (U, bool), func()) {
+		next, stop := s.iterator()
+		return func() (U, bool) {
+			v, ok := next()
+			if !ok {
+				var zero U
+				return zero, false
+			}
+			return mapper(v), true
+		}, stop
+	})
+}
+
+// FlatMap returns a stream consisting of the elements of the streams produced by applying the given function to the elements of the stream.
+func FlatMap[T, U any](s Stream[T], mapper func(item T) Stream[U]) Stream[U] {
+	return fromStoppable(func() (func() (U, bool), func()) {
+		next, stop := s.iterator()
+		inner, stopInner := FromSlice[U](nil).iterator()
+
+		pull := func() (U, bool) {
+			for {
+				if u, ok := inner(); ok {
+					return u, true
+				}
+				stopInner()
+				v, ok := next()
+				if !ok {
+					var zero U
+					return zero, false
+				}
+				inner, stopInner = mapper(v).iterator()
+			}
+		}
+		return pull, func() {
+			stopInner()
+			stop()
+		}
+	})
+}
+
+// GroupBy groups the elements of the stream by the key returned by the given function, keeping their order in each group.
+func GroupBy[T any, K comparable](s Stream[T], key func(item T) K) map[K][]T {
+	groups := make(map[K][]T)
+	s.ForEach(func(item T) {
+		k := key(item)
+		groups[k] = append(groups[k], item)
+	})
+	return groups
+}
+
+// Partition splits the elements of the stream into the ones matching the given predicate and the other ones.
+func Partition[T any](s Stream[T], predicate func(item T) bool) ([]T, []T) {
+	matched, rest := make([]T, 0), make([]T, 0)
+	s.ForEach(func(item T) {
+		if predicate(item) {
+			matched = append(matched, item)
+		} else {
+			rest = append(rest, item)
+		}
+	})
+	return matched, rest
+}
+
+// Zip returns a stream of the pairs of elements of the two streams at the same position.
+// Unlike tuple.Zip2, the stream ends with the shortest stream, so that infinite streams can be zipped.
+func Zip[A, B any](a Stream[A], b Stream[B]) Stream[tuple.Tuple2[A, B]] {
+	return fromStoppable(func() (func() (tuple.Tuple2[A, B], bool), func()) {
+		nextA, stopA := a.iterator()
+		nextB, stopB := b.iterator()
+		return func() (tuple.Tuple2[A, B], bool) {
+				va, okA := nextA()
+				if !okA {
+					return tuple.Tuple2[A, B]{}, false
+				}
+				vb, okB := nextB()
+				if !okB {
+					return tuple.Tuple2[A, B]{}, false
+				}
+				return tuple.NewTuple2(va, vb), true
+			}, func() {
+				stopA()
+				stopB()
+			}
+	})
+}
+
+// Chunk returns a stream of the elements of the stream split into slices of the given size.
+// The last chunk may be smaller. It panics if size is not positive.
+func Chunk[T any](s Stream[T], size int) Stream[[]T] {
+	return Window(s, size, size)
+}
+
+// Window returns a stream of the windows of the given size over the elements of the stream, each
+// window starting step elements after the previous one. The last windows may be smaller when step
+// is less than size. It panics if size or step is not positive.
+func Window[T any](s Stream[T], size, step int) Stream[[]T] {
+	if size <= 0 {
+		panic("stream.Window: param size should be positive")
+	} else if step <= 0 {
+		panic("stream.Window: param step should be positive")
+	}
+
+	return fromStoppable(func() (func() ([]T, bool), func()) {
+		next, stop := s.iterator()
+		var window []T
+		started, done := false, false
+
+		return func() ([]T, bool) {
+			if started {
+				// move to the start of the next window
+				if step < len(window) {
+					window = append([]T{}, window[step:]...)
+				} else {
+					for i := len(window); i < step && !done; i++ {
+						_, ok := next()
+						done = !ok
+					}
+					window = nil
+				}
+			}
+			started = true
+
+			// a window without new elements would only repeat the end of the previous one
+			added := 0
+			for len(window) < size && !done {
+				v, ok := next()
+				if !ok {
+					done = true
+					break
+				}
+				window = append(window, v)
+				added++
+			}
+			if added == 0 {
+				return nil, false
+			}
+
+			return append([]T{}, window...), true
+		}, stop
+	})
+}
+
+// ToMap collects the elements of the stream into a map, with the keys and values returned by the
+// given functions. A later element replaces an earlier one with the same key.
+func ToMap[T any, K comparable, V any](s Stream[T], key func(item T) K, value func(item T) V) map[K]V {
+	m := make(map[K]V)
+	s.ForEach(func(item T) {
+		m[key(item)] = value(item)
+	})
+	return m
+}
+
+// ToSet collects the elements of the stream into a set.
+func ToSet[T comparable](s Stream[T]) set.Set[T] {
+	result := set.NewSet[T]()
+	s.ForEach(func(item T) {
+		result.Add(item)
+	})
+	return result
+}
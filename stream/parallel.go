@@ -0,0 +1,328 @@
+package stream
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ParallelStream is a stream whose operations run on a bounded number of goroutines.
+//
+// The elements are still pulled one by one from the source, only the functions given to Map,
+// Filter, ForEach and Reduce run in parallel, so it pays off for costly functions only. By default
+// the results keep the order of the source, Unordered gives them as soon as they are ready.
+type ParallelStream[T any] struct {
+	// tasks computes each element, a task returns false when its element is filtered out.
+	tasks   taskSource[T]
+	workers int
+	ordered bool
+}
+
+// Parallel returns a parallel stream running its operations on n goroutines,
+// n <= 0 means runtime.GOMAXPROCS(0).
+func (s Stream[T]) Parallel(n int) ParallelStream[T] {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+
+	tasks := func() (func() (func() (T, bool), bool), func()) {
+		next, stop := s.iterator()
+		return func() (func() (T, bool), bool) {
+			item, ok := next()
+			if !ok {
+				return nil, false
+			}
+			return func() (T, bool) {
+				return item, true
+			}, true
+		}, stop
+	}
+	return ParallelStream[T]{tasks: tasks, workers: n, ordered: true}
+}
+
+// Unordered returns a parallel stream giving its elements as soon as they are ready,
+// instead of in the order of the source.
+func (p ParallelStream[T]) Unordered() ParallelStream[T] {
+	p.ordered = false
+	return p
+}
+
+// Ordered returns a parallel stream giving its elements in the order of the source.
+func (p ParallelStream[T]) Ordered() ParallelStream[T] {
+	p.ordered = true
+	return p
+}
+
+// Map returns a parallel stream applying the given function to its elements.
+func (p ParallelStream[T]) Map(mapper func(item T) T) ParallelStream[T] {
+	return ParallelMap(p, mapper)
+}
+
+// ParallelMap returns a parallel stream applying the given function to the elements of p, see Map.
+func ParallelMap[T, U any](p ParallelStream[T], mapper func(item T) U) ParallelStream[U] {
+	tasks := mapTasks(p.tasks, func(task func() (T, bool)) func() (U, bool) {
+		return func() (U, bool) {
+			v, ok := task()
+			if !ok {
+				var zero U
+				return zero, false
+			}
+			return mapper(v), true
+		}
+	})
+	return ParallelStream[U]{tasks: tasks, workers: p.workers, ordered: p.ordered}
+}
+
+// Filter returns a parallel stream keeping the elements matching the given predicate.
+func (p ParallelStream[T]) Filter(predicate func(item T) bool) ParallelStream[T] {
+	tasks := mapTasks(p.tasks, func(task func() (T, bool)) func() (T, bool) {
+		return func() (T, bool) {
+			v, ok := task()
+			return v, ok && predicate(v)
+		}
+	})
+	return ParallelStream[T]{tasks: tasks, workers: p.workers, ordered: p.ordered}
+}
+
+// ForEach performs an action for each element, in parallel and in no particular order.
+func (p ParallelStream[T]) ForEach(action func(item T)) {
+	runTasks(p.tasks, p.workers, nil, func(tasks <-chan func() (T, bool)) {
+		for task := range tasks {
+			if v, ok := task(); ok {
+				action(v)
+			}
+		}
+	})
+}
+
+// Reduce performs a reduction of the elements: each goroutine accumulates its elements from
+// identity, then the partial results are combined. Since the elements are spread among the
+// goroutines, identity must be neutral and both functions associative and commutative.
+func (p ParallelStream[T]) Reduce(identity T, accumulator func(a, b T) T, combiner func(a, b T) T) T {
+	var mu sync.Mutex
+	partials := make([]T, 0, p.workers)
+
+	runTasks(p.tasks, p.workers, nil, func(tasks <-chan func() (T, bool)) {
+		partial := identity
+		for task := range tasks {
+			if v, ok := task(); ok {
+				partial = accumulator(partial, v)
+			}
+		}
+		mu.Lock()
+		partials = append(partials, partial)
+		mu.Unlock()
+	})
+
+	result := identity
+	for _, partial := range partials {
+		result = combiner(result, partial)
+	}
+	return result
+}
+
+// ToSlice returns the elements of the stream.
+func (p ParallelStream[T]) ToSlice() []T {
+	result := make([]T, 0)
+	p.each(func(item T) bool {
+		result = append(result, item)
+		return true
+	})
+	return result
+}
+
+// Count returns the count of elements in the stream.
+func (p ParallelStream[T]) Count() int {
+	return len(p.ToSlice())
+}
+
+// Sequential returns a sequential stream of the elements, which runs the parallel operations
+// when it is consumed.
+func (p ParallelStream[T]) Sequential() Stream[T] {
+	return fromStoppable(func() (func() (T, bool), func()) {
+		return FromSlice(p.ToSlice()).iterator()
+	})
+}
+
+// each calls fn for each element in the caller goroutine, until fn returns false.
+func (p ParallelStream[T]) each(fn func(item T) bool) {
+	if p.ordered {
+		p.eachOrdered(fn)
+		return
+	}
+
+	out := make(chan T)
+	stop := make(chan struct{})
+	panics := make(chan any, 1)
+
+	go func() {
+		defer close(out)
+		defer func() {
+			panics <- recover()
+		}()
+
+		runTasks(p.tasks, p.workers, stop, func(tasks <-chan func() (T, bool)) {
+			for task := range tasks {
+				if v, ok := task(); ok {
+					select {
+					case out <- v:
+					case <-stop:
+					}
+				}
+			}
+		})
+	}()
+
+	stopped := false
+	for v := range out {
+		if !stopped && !fn(v) {
+			stopped = true
+			close(stop)
+		}
+	}
+	if r := <-panics; r != nil {
+		panic(r)
+	}
+}
+
+// taskSource returns new iterators over the tasks of a parallel stream, along with the function
+// releasing them. It is not a Stream, whose Parallel method would instantiate ParallelStream for
+// the tasks, and so on.
+type taskSource[T any] func() (func() (func() (T, bool), bool), func())
+
+// mapTasks returns the tasks of source transformed by fn, as they are pulled.
+func mapTasks[T, U any](source taskSource[T], fn func(task func() (T, bool)) func() (U, bool)) taskSource[U] {
+	return func() (func() (func() (U, bool), bool), func()) {
+		next, stop := source()
+		return func() (func() (U, bool), bool) {
+			task, ok := next()
+			if !ok {
+				return nil, false
+			}
+			return fn(task), true
+		}, stop
+	}
+}
+
+type parallelResult[T any] struct {
+	value T
+	ok    bool
+}
+
+// eachOrdered is each keeping the order of the source: the results are queued in the order of
+// their tasks, at most one per goroutine ahead of the result being waited for.
+func (p ParallelStream[T]) eachOrdered(fn func(item T) bool) {
+	pending := make(chan chan parallelResult[T], p.workers)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	panics := make(chan any, 1)
+
+	// the tasks are pulled by the dispatcher of runTasks, in the order of the source
+	tasks := mapTasks(p.tasks, func(task func() (T, bool)) func() (T, bool) {
+		r := make(chan parallelResult[T], 1)
+		select {
+		case pending <- r:
+		case <-stop:
+		}
+		return func() (T, bool) {
+			defer close(r)
+			v, ok := task()
+			r <- parallelResult[T]{value: v, ok: ok}
+			return v, ok
+		}
+	})
+
+	go func() {
+		defer close(pending)
+		defer func() {
+			panics <- recover()
+			close(done)
+		}()
+
+		runTasks(tasks, p.workers, stop, func(tasks <-chan func() (T, bool)) {
+			for task := range tasks {
+				task()
+			}
+		})
+	}()
+
+	stopped := false
+	for r := range pending {
+		if stopped {
+			continue
+		}
+
+		var (
+			res parallelResult[T]
+			ok  bool
+		)
+		select {
+		case res, ok = <-r:
+		case <-done:
+			// every task run is done, the remaining ones were never run
+			select {
+			case res, ok = <-r:
+			default:
+			}
+		}
+
+		if !ok || (res.ok && !fn(res.value)) {
+			stopped = true
+			close(stop)
+		}
+	}
+	if r := <-panics; r != nil {
+		panic(r)
+	}
+}
+
+// runTasks pulls the tasks in the caller goroutine and sends them to work, which runs on the given
+// number of goroutines. It stops pulling the tasks when stop is closed, releases them in the caller
+// goroutine, and raises again in the caller goroutine the first panic of work.
+func runTasks[T any](tasks taskSource[T], workers int, stop <-chan struct{}, work func(tasks <-chan func() (T, bool))) {
+	ch := make(chan func() (T, bool))
+	panicked := make(chan struct{})
+
+	var (
+		wg        sync.WaitGroup
+		once      sync.Once
+		recovered any
+	)
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					once.Do(func() {
+						recovered = r
+						close(panicked)
+					})
+					// let the other goroutines finish the dispatched tasks
+					for range ch {
+					}
+				}
+			}()
+
+			work(ch)
+		}()
+	}
+
+	next, release := tasks()
+	defer release()
+dispatch:
+	for task, ok := next(); ok; task, ok = next() {
+		select {
+		case ch <- task:
+		case <-stop:
+			break dispatch
+		case <-panicked:
+			break dispatch
+		}
+	}
+	close(ch)
+	wg.Wait()
+
+	if recovered != nil {
+		panic(recovered)
+	}
+}
@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"fmt"
+	"testing"
+)
+
+// spin is a CPU-bound mapper, its cost grows with work.
+func spin(work int) func(n int) int {
+	return func(n int) int {
+		x := n
+		for i := 0; i < work; i++ {
+			x = x*1664525 + 1013904223
+		}
+		return x
+	}
+}
+
+// The parallel stream pays for its goroutines and channels, about a microsecond or two per element:
+// with a cheap mapper the sequential stream is much faster, the parallel one only wins once the
+// mapper costs several times that overhead and several CPUs are available. Run with -cpu 1,4 to
+// see where the crossover lies on a given machine, there is none with a single CPU.
+func BenchmarkMap(b *testing.B) {
+	source := FromRange(0, 999, 1)
+
+	for _, work := range []int{10, 100, 1000, 10000} {
+		mapper := spin(work)
+
+		b.Run(fmt.Sprintf("work=%d/sequential", work), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				source.Map(mapper).ToSlice()
+			}
+		})
+		b.Run(fmt.Sprintf("work=%d/parallel-ordered", work), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				source.Parallel(0).Map(mapper).ToSlice()
+			}
+		})
+		b.Run(fmt.Sprintf("work=%d/parallel-unordered", work), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				source.Parallel(0).Unordered().Map(mapper).ToSlice()
+			}
+		})
+	}
+}
+
+func BenchmarkReduce(b *testing.B) {
+	source := FromRange(0, 999, 1)
+	add := func(a, b int) int { return a + b }
+
+	for _, work := range []int{10, 10000} {
+		mapper := spin(work)
+		accumulate := func(acc, n int) int { return acc + mapper(n) }
+
+		b.Run(fmt.Sprintf("work=%d/sequential", work), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				source.Reduce(0, accumulate)
+			}
+		})
+		b.Run(fmt.Sprintf("work=%d/parallel", work), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				source.Parallel(0).Reduce(0, accumulate, add)
+			}
+		})
+	}
+}
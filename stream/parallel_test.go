@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sllt/af/internal"
+)
+
+func TestParallel(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestParallel")
+
+	var running, maxRunning int32
+	slowSquare := func(n int) int {
+		r := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if r <= m || atomic.CompareAndSwapInt32(&maxRunning, m, r) {
+				break
+			}
+		}
+		// the first elements are the slowest
+		time.Sleep(time.Duration(20-n) * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return n * n
+	}
+
+	s := FromRange(1, 10, 1).Parallel(4).Map(slowSquare)
+	assert.Equal([]int{1, 4, 9, 16, 25, 36, 49, 64, 81, 100}, s.ToSlice())
+	assert.ShouldBeTrue(atomic.LoadInt32(&maxRunning) <= 4)
+	assert.ShouldBeTrue(atomic.LoadInt32(&maxRunning) > 1)
+
+	unordered := s.Unordered().Filter(func(n int) bool { return n%2 == 0 }).ToSlice()
+	sort.Ints(unordered)
+	assert.Equal([]int{4, 16, 36, 64, 100}, unordered)
+
+	strs := ParallelMap(Of(1, 2, 3).Parallel(2), func(n int) string { return string(rune('a' + n - 1)) })
+	assert.Equal([]string{"a", "b", "c"}, strs.ToSlice())
+	assert.Equal(3, strs.Count())
+	assert.Equal([]string{"a", "b"}, strs.Sequential().Limit(2).ToSlice())
+	assert.Equal([]int{}, FromSlice([]int{}).Parallel(2).ToSlice())
+}
+
+func TestParallel_ForEachAndReduce(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestParallel_ForEachAndReduce")
+
+	var sum int64
+	FromRange(1, 100, 1).Parallel(0).ForEach(func(n int) {
+		atomic.AddInt64(&sum, int64(n))
+	})
+	assert.Equal(int64(5050), atomic.LoadInt64(&sum))
+
+	add := func(a, b int) int { return a + b }
+	assert.Equal(5050, FromRange(1, 100, 1).Parallel(4).Reduce(0, add, add))
+	assert.Equal(0, FromSlice([]int{}).Parallel(4).Reduce(0, add, add))
+
+	count := FromRange(1, 100, 1).Parallel(4).Reduce(0, func(acc, _ int) int { return acc + 1 }, add)
+	assert.Equal(100, count)
+}
+
+func TestParallel_Panic(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestParallel_Panic")
+
+	for _, unordered := range []bool{false, true} {
+		p := FromRange(1, 100, 1).Parallel(4)
+		if unordered {
+			p = p.Unordered()
+		}
+		p = p.Map(func(n int) int {
+			if n == 50 {
+				panic("boom")
+			}
+			return n
+		})
+
+		func() {
+			defer func() {
+				assert.Equal("boom", recover())
+			}()
+			p.ToSlice()
+		}()
+
+		func() {
+			defer func() {
+				assert.Equal("boom", recover())
+			}()
+			p.ForEach(func(int) {})
+		}()
+	}
+}
@@ -0,0 +1,30 @@
+//go:build go1.23
+
+package stream
+
+import (
+	"iter"
+
+	"github.com/sllt/af/tuple"
+)
+
+// FromSeq creates a lazy stream from a sequence, which is ranged over by each terminal operation.
+//
+// The sequence is pulled with iter.Pull. When a terminal operation stops before the end of the
+// sequence, as FindFirst or Limit do, the sequence is stopped before the operation returns.
+func FromSeq[T any](seq iter.Seq[T]) Stream[T] {
+	return fromStoppable(func() (func() (T, bool), func()) {
+		return iter.Pull(seq)
+	})
+}
+
+// FromSeq2 creates a lazy stream of the pairs of a sequence, see FromSeq.
+func FromSeq2[K, V any](seq iter.Seq2[K, V]) Stream[tuple.Tuple2[K, V]] {
+	return fromStoppable(func() (func() (tuple.Tuple2[K, V], bool), func()) {
+		next, stop := iter.Pull2(seq)
+		return func() (tuple.Tuple2[K, V], bool) {
+			k, v, ok := next()
+			return tuple.NewTuple2(k, v), ok
+		}, stop
+	})
+}
@@ -0,0 +1,88 @@
+//go:build go1.23
+
+package stream
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/sllt/af/internal"
+	"github.com/sllt/af/tuple"
+)
+
+func TestFromSeq(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestFromSeq")
+
+	s := FromSeq(slices.Values([]int{1, 2, 3, 4}))
+	assert.Equal([]int{1, 2, 3, 4}, s.ToSlice())
+	assert.Equal([]int{1, 2}, s.Limit(2).ToSlice())
+
+	first, ok := s.FindFirst()
+	assert.Equal(1, first)
+	assert.Equal(true, ok)
+
+	pairs := FromSeq2(maps.All(map[string]int{"a": 1}))
+	assert.Equal([]tuple.Tuple2[string, int]{tuple.NewTuple2("a", 1)}, pairs.ToSlice())
+}
+
+func TestRangeOverStream(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestRangeOverStream")
+
+	result := []int{}
+	for n := range FromRange(1, 10, 1).Filter(func(n int) bool { return n%2 == 1 }).All() {
+		if n > 5 {
+			break
+		}
+		result = append(result, n)
+	}
+	assert.Equal([]int{1, 3, 5}, result)
+
+	// back and forth without copying
+	assert.Equal([]int{2, 4}, slices.Collect(Map(FromSeq(slices.Values([]int{1, 2})), func(n int) int { return n * 2 }).All()))
+}
+
+func TestFromSeq_Stop(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestFromSeq_Stop")
+
+	stopped := 0
+	seq := func(yield func(int) bool) {
+		defer func() { stopped++ }()
+		for i := 1; i <= 4; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	s := FromSeq(seq)
+
+	// the sequence is stopped before the operation returns, in the caller goroutine
+	s.FindFirst()
+	assert.Equal(1, stopped)
+
+	assert.Equal([]int{1, 2}, s.Limit(2).ToSlice())
+	assert.Equal(2, stopped)
+
+	assert.Equal(true, s.AnyMatch(func(n int) bool { return n == 2 }))
+	assert.Equal(3, stopped)
+
+	assert.Equal([]int{1, 1, 2}, FlatMap(Of(1, 2), func(n int) Stream[int] { return s.Limit(n) }).ToSlice())
+	assert.Equal(5, stopped)
+
+	assert.Equal(2, Zip(Of(1, 2), s).Count())
+	assert.Equal(6, stopped)
+
+	assert.Equal(1, s.Parallel(2).Filter(func(n int) bool { return n == 1 }).Count())
+	assert.Equal(7, stopped)
+
+	iter := s.Iterator()
+	assert.Equal(true, iter.HasNext())
+	iter.Stop()
+	assert.Equal(8, stopped)
+}
@@ -5,6 +5,7 @@ package stream
 import (
 	"bytes"
 	"encoding/gob"
+	"sync"
 
 	"github.com/sllt/af/slice"
 	"golang.org/x/exp/constraints"
@@ -44,8 +45,15 @@ import (
 // 	Concat(streams ...StreamI[T]) StreamI[T]
 // }
 
+// Stream is a lazy sequence of elements: the operations returning a stream only describe
+// the pipeline, the elements are pulled one by one when a terminal operation such as ToSlice,
+// Count or ForEach runs. Each terminal operation runs the pipeline again.
 type Stream[T any] struct {
+	// source holds the elements of a stream created from a slice.
 	source []T
+	// iterate returns a new iterator of a lazy stream, along with the function releasing its
+	// sources once it is no longer used. It is nil for a slice stream.
+	iterate func() (func() (T, bool), func())
 }
 
 // Of creates a stream whose elements are the specified values.
@@ -53,20 +61,10 @@ func Of[T any](elems ...T) Stream[T] {
 	return FromSlice(elems)
 }
 
-// Generate stream where each element is generated by the provided generater function
+// Generate stream where each element is generated by the provided generater function.
+// The generator is called by each terminal operation, the stream ends when its function returns false.
 func Generate[T any](generator func() func() (item T, ok bool)) Stream[T] {
-	source := make([]T, 0)
-
-	var zeroValue T
-	for next, item, ok := generator(), zeroValue, true; ok; {
-
-		item, ok = next()
-		if ok {
-			source = append(source, item)
-		}
-	}
-
-	return FromSlice(source)
+	return fromIterator(generator)
 }
 
 // FromSlice creates stream from slice.
@@ -74,15 +72,49 @@ func FromSlice[T any](source []T) Stream[T] {
 	return Stream[T]{source: source}
 }
 
-// FromChannel creates stream from channel.
+// FromChannel creates stream from channel. The channel is read as the elements are consumed,
+// the elements read are kept so that the stream can be consumed several times.
 func FromChannel[T any](source <-chan T) Stream[T] {
-	s := make([]T, 0)
-
-	for v := range source {
-		s = append(s, v)
-	}
+	return memoize(func() (T, bool) {
+		v, ok := <-source
+		return v, ok
+	})
+}
 
-	return FromSlice(s)
+// memoize creates a stream over the elements returned by next, which is called once per element:
+// the elements are kept so that the stream can be consumed several times.
+func memoize[T any](next func() (T, bool)) Stream[T] {
+	var (
+		mu     sync.Mutex
+		buffer []T
+		done   bool
+	)
+
+	return fromIterator(func() func() (T, bool) {
+		i := 0
+		return func() (T, bool) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if i < len(buffer) {
+				i++
+				return buffer[i-1], true
+			}
+
+			var zero T
+			if done {
+				return zero, false
+			}
+			v, ok := next()
+			if !ok {
+				done = true
+				return zero, false
+			}
+			buffer = append(buffer, v)
+			i++
+			return v, true
+		}
+	})
 }
 
 // FromRange creates a number stream from start to end. both start and end are included. [start, end]
@@ -94,41 +126,117 @@ func FromRange[T constraints.Integer | constraints.Float](start, end, step T) St
 	}
 
 	l := int((end-start)/step) + 1
-	source := make([]T, l)
 
-	for i := 0; i < l; i++ {
-		source[i] = start + (T(i) * step)
-	}
-
-	return FromSlice(source)
+	return fromIterator(func() func() (T, bool) {
+		i := 0
+		return func() (T, bool) {
+			if i >= l {
+				return 0, false
+			}
+			v := start + (T(i) * step)
+			i++
+			return v, true
+		}
+	})
 }
 
 // Concat creates a lazily concatenated stream whose elements are all the elements of the first stream followed by all the elements of the second stream.
 func Concat[T any](a, b Stream[T]) Stream[T] {
-	source := make([]T, 0)
+	return fromStoppable(func() (func() (T, bool), func()) {
+		next, stop := a.iterator()
+		second := false
+
+		pull := func() (T, bool) {
+			for {
+				if v, ok := next(); ok || second {
+					return v, ok
+				}
+				stop()
+				next, stop = b.iterator()
+				second = true
+			}
+		}
+		return pull, func() {
+			stop()
+		}
+	})
+}
 
-	source = append(source, a.source...)
-	source = append(source, b.source...)
+// fromIterator creates a lazy stream over iterators having nothing to release.
+func fromIterator[T any](iterate func() func() (T, bool)) Stream[T] {
+	return fromStoppable(func() (func() (T, bool), func()) {
+		return iterate(), func() {}
+	})
+}
 
-	return FromSlice(source)
+// fromStoppable creates a lazy stream over iterators coming with the function releasing them.
+func fromStoppable[T any](iterate func() (func() (T, bool), func())) Stream[T] {
+	return Stream[T]{iterate: iterate}
 }
 
-// Distinct returns a stream that removes the duplicated items.
-func (s Stream[T]) Distinct() Stream[T] {
-	source := make([]T, 0)
+// iterator returns a new iterator over the elements of the stream, and the function releasing it.
+// The function must be called once the iterator is no longer used, it may be called several
+// times. The stream operations forward it to the iterators they pull from.
+func (s Stream[T]) iterator() (func() (T, bool), func()) {
+	if s.iterate != nil {
+		return s.iterate()
+	}
 
-	distinct := map[string]bool{}
+	source, i := s.source, 0
+	return func() (T, bool) {
+		if i >= len(source) {
+			var zero T
+			return zero, false
+		}
+		i++
+		return source[i-1], true
+	}, func() {}
+}
 
-	for _, v := range s.source {
-		// todo: performance issue
-		k := hashKey(v)
-		if _, ok := distinct[k]; !ok {
-			distinct[k] = true
-			source = append(source, v)
+// each calls fn for each element of the stream, until fn returns false.
+func (s Stream[T]) each(fn func(item T) bool) {
+	next, stop := s.iterator()
+	defer stop()
+	for v, ok := next(); ok; v, ok = next() {
+		if !fn(v) {
+			return
 		}
 	}
+}
+
+// collect returns the elements of the stream in a new slice.
+func (s Stream[T]) collect() []T {
+	if s.iterate == nil {
+		return append(make([]T, 0, len(s.source)), s.source...)
+	}
 
-	return FromSlice(source)
+	source := make([]T, 0)
+	s.each(func(item T) bool {
+		source = append(source, item)
+		return true
+	})
+	return source
+}
+
+// Distinct returns a stream that removes the duplicated items.
+func (s Stream[T]) Distinct() Stream[T] {
+	return fromStoppable(func() (func() (T, bool), func()) {
+		next, stop := s.iterator()
+		distinct := map[string]bool{}
+
+		return func() (T, bool) {
+			for v, ok := next(); ok; v, ok = next() {
+				// todo: performance issue
+				k := hashKey(v)
+				if _, ok := distinct[k]; !ok {
+					distinct[k] = true
+					return v, true
+				}
+			}
+			var zero T
+			return zero, false
+		}, stop
+	})
 }
 
 func hashKey(data any) string {
@@ -143,35 +251,38 @@ func hashKey(data any) string {
 
 // Filter returns a stream consisting of the elements of this stream that match the given predicate.
 func (s Stream[T]) Filter(predicate func(item T) bool) Stream[T] {
-	source := make([]T, 0)
-
-	for _, v := range s.source {
-		if predicate(v) {
-			source = append(source, v)
-		}
-	}
-
-	return FromSlice(source)
+	return fromStoppable(func() (func() (T, bool), func()) {
+		next, stop := s.iterator()
+		return func() (T, bool) {
+			for v, ok := next(); ok; v, ok = next() {
+				if predicate(v) {
+					return v, true
+				}
+			}
+			var zero T
+			return zero, false
+		}, stop
+	})
 }
 
 // Map returns a stream consisting of the elements of this stream that apply the given function to elements of stream.
+// Use the Map function to change the type of the elements.
 func (s Stream[T]) Map(mapper func(item T) T) Stream[T] {
-	source := make([]T, s.Count())
-
-	for i, v := range s.source {
-		source[i] = mapper(v)
-	}
-
-	return FromSlice(source)
+	return Map(s, mapper)
 }
 
 // Peek returns a stream consisting of the elements of this stream, additionally performing the provided action on each element as elements are consumed from the resulting stream.
 func (s Stream[T]) Peek(consumer func(item T)) Stream[T] {
-	for _, v := range s.source {
-		consumer(v)
-	}
-
-	return s
+	return fromStoppable(func() (func() (T, bool), func()) {
+		next, stop := s.iterator()
+		return func() (T, bool) {
+			v, ok := next()
+			if ok {
+				consumer(v)
+			}
+			return v, ok
+		}, stop
+	})
 }
 
 // Skip returns a stream consisting of the remaining elements of this stream after discarding the first n elements of the stream.
@@ -181,23 +292,28 @@ func (s Stream[T]) Skip(n int) Stream[T] {
 		return s
 	}
 
-	source := make([]T, 0)
-	l := len(s.source)
-
-	if n > l {
-		return FromSlice(source)
-	}
-
-	for i := n; i < l; i++ {
-		source = append(source, s.source[i])
-	}
-
-	return FromSlice(source)
+	return fromStoppable(func() (func() (T, bool), func()) {
+		next, stop := s.iterator()
+		skipped := false
+		return func() (T, bool) {
+			if !skipped {
+				skipped = true
+				for i := 0; i < n; i++ {
+					if _, ok := next(); !ok {
+						var zero T
+						return zero, false
+					}
+				}
+			}
+			return next()
+		}, stop
+	})
 }
 
 // Limit returns a stream consisting of the elements of this stream, truncated to be no longer than maxSize in length.
+// The elements after the first maxSize ones are not pulled from this stream.
 func (s Stream[T]) Limit(maxSize int) Stream[T] {
-	if s.source == nil {
+	if s.iterate == nil && s.source == nil {
 		return s
 	}
 
@@ -205,35 +321,40 @@ func (s Stream[T]) Limit(maxSize int) Stream[T] {
 		return FromSlice([]T{})
 	}
 
-	source := make([]T, 0, maxSize)
-
-	for i := 0; i < len(s.source) && i < maxSize; i++ {
-		source = append(source, s.source[i])
-	}
-
-	return FromSlice(source)
+	return fromStoppable(func() (func() (T, bool), func()) {
+		next, stop := s.iterator()
+		n := 0
+		return func() (T, bool) {
+			if n >= maxSize {
+				var zero T
+				return zero, false
+			}
+			n++
+			return next()
+		}, stop
+	})
 }
 
 // AllMatch returns whether all elements of this stream match the provided predicate.
 func (s Stream[T]) AllMatch(predicate func(item T) bool) bool {
-	for _, v := range s.source {
-		if !predicate(v) {
-			return false
-		}
-	}
+	result := true
+	s.each(func(item T) bool {
+		result = predicate(item)
+		return result
+	})
 
-	return true
+	return result
 }
 
 // AnyMatch returns whether any elements of this stream match the provided predicate.
 func (s Stream[T]) AnyMatch(predicate func(item T) bool) bool {
-	for _, v := range s.source {
-		if predicate(v) {
-			return true
-		}
-	}
+	result := false
+	s.each(func(item T) bool {
+		result = predicate(item)
+		return !result
+	})
 
-	return false
+	return result
 }
 
 // NoneMatch returns whether no elements of this stream match the provided predicate.
@@ -243,56 +364,65 @@ func (s Stream[T]) NoneMatch(predicate func(item T) bool) bool {
 
 // ForEach performs an action for each element of this stream.
 func (s Stream[T]) ForEach(action func(item T)) {
-	for _, v := range s.source {
-		action(v)
-	}
+	s.each(func(item T) bool {
+		action(item)
+		return true
+	})
 }
 
 // Reduce performs a reduction on the elements of this stream, using an associative accumulation function, and returns an Optional describing the reduced value, if any.
 func (s Stream[T]) Reduce(initial T, accumulator func(a, b T) T) T {
-	for _, v := range s.source {
-		initial = accumulator(initial, v)
-	}
+	s.each(func(item T) bool {
+		initial = accumulator(initial, item)
+		return true
+	})
 
 	return initial
 }
 
 // Count returns the count of elements in the stream.
 func (s Stream[T]) Count() int {
-	return len(s.source)
+	if s.iterate == nil {
+		return len(s.source)
+	}
+
+	count := 0
+	s.each(func(T) bool {
+		count++
+		return true
+	})
+	return count
 }
 
 // FindFirst returns the first element of this stream and true, or zero value and false if the stream is empty.
 func (s Stream[T]) FindFirst() (T, bool) {
-	var result T
-
-	if s.source == nil || len(s.source) == 0 {
-		return result, false
-	}
-
-	return s.source[0], true
+	next, stop := s.iterator()
+	defer stop()
+	return next()
 }
 
 // FindLast returns the last element of this stream and true, or zero value and false if the stream is empty.
 func (s Stream[T]) FindLast() (T, bool) {
 	var result T
+	found := false
 
-	if s.source == nil || len(s.source) == 0 {
-		return result, false
-	}
+	s.each(func(item T) bool {
+		result, found = item, true
+		return true
+	})
 
-	return s.source[len(s.source)-1], true
+	return result, found
 }
 
 // Reverse returns a stream whose elements are reverse order of given stream.
 func (s Stream[T]) Reverse() Stream[T] {
-	l := len(s.source)
-	source := make([]T, l)
-
-	for i := 0; i < l; i++ {
-		source[i] = s.source[l-1-i]
-	}
-	return FromSlice(source)
+	return fromStoppable(func() (func() (T, bool), func()) {
+		source := s.collect()
+		for i, j := 0, len(source)-1; i < j; i, j = i+1, j-1 {
+			source[i], source[j] = source[j], source[i]
+		}
+		return FromSlice(source).iterator()
+	})
 }
 
 // Range returns a stream whose elements are in the range from start(included) to end(excluded) original stream.
@@ -307,65 +437,55 @@ func (s Stream[T]) Range(start, end int) Stream[T] {
 		return FromSlice([]T{})
 	}
 
-	source := make([]T, 0)
-
-	if end > len(s.source) {
-		end = len(s.source)
-	}
-
-	for i := start; i < end; i++ {
-		source = append(source, s.source[i])
-	}
-
-	return FromSlice(source)
+	return fromStoppable(s.Skip(start).Limit(end - start).iterator)
 }
 
 // Sorted returns a stream consisting of the elements of this stream, sorted according to the provided less function.
 func (s Stream[T]) Sorted(less func(a, b T) bool) Stream[T] {
-	source := []T{}
-	source = append(source, s.source...)
-
-	slice.SortBy(source, less)
-
-	return FromSlice(source)
+	return fromStoppable(func() (func() (T, bool), func()) {
+		source := s.collect()
+		slice.SortBy(source, less)
+		return FromSlice(source).iterator()
+	})
 }
 
 // Max returns the maximum element of this stream according to the provided less function.
 // less: a > b
 func (s Stream[T]) Max(less func(a, b T) bool) (T, bool) {
 	var max T
+	found := false
 
-	if len(s.source) == 0 {
-		return max, false
-	}
-
-	for i, v := range s.source {
-		if less(v, max) || i == 0 {
-			max = v
+	s.each(func(item T) bool {
+		if !found || less(item, max) {
+			max = item
 		}
-	}
-	return max, true
+		found = true
+		return true
+	})
+	return max, found
 }
 
 // Min returns the minimum element of this stream according to the provided less function.
 // less: a < b
 func (s Stream[T]) Min(less func(a, b T) bool) (T, bool) {
 	var min T
+	found := false
 
-	if len(s.source) == 0 {
-		return min, false
-	}
-
-	for i, v := range s.source {
-		if less(v, min) || i == 0 {
-			min = v
+	s.each(func(item T) bool {
+		if !found || less(item, min) {
+			min = item
 		}
-	}
+		found = true
+		return true
+	})
 
-	return min, true
+	return min, found
 }
 
 // ToSlice return the elements in the stream.
 func (s Stream[T]) ToSlice() []T {
-	return s.source
+	if s.iterate == nil {
+		return s.source
+	}
+	return s.collect()
 }
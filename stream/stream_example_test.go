@@ -384,3 +384,25 @@ func ExampleStream_Count() {
 	// 3
 	// 0
 }
+
+func ExampleMap() {
+	original := FromSlice([]int{1, 2, 3})
+
+	mapped := Map(original, func(n int) string { return fmt.Sprint("#", n) })
+
+	fmt.Println(mapped.ToSlice())
+
+	// Output:
+	// [#1 #2 #3]
+}
+
+func ExampleChunk() {
+	original := FromSlice([]int{1, 2, 3, 4, 5})
+
+	chunks := Chunk(original, 2)
+
+	fmt.Println(chunks.ToSlice())
+
+	// Output:
+	// [[1 2] [3 4] [5]]
+}
@@ -2,9 +2,11 @@ package stream
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/sllt/af/internal"
+	"github.com/sllt/af/tuple"
 )
 
 func TestOf(t *testing.T) {
@@ -381,3 +383,127 @@ func TestStream_Min(t *testing.T) {
 	assert.Equal(1, max)
 	assert.Equal(true, ok)
 }
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestLazy")
+
+	pulled := 0
+	naturals := Generate(func() func() (int, bool) {
+		n := 0
+		return func() (int, bool) {
+			pulled++
+			n++
+			return n, true
+		}
+	})
+
+	// an infinite stream is only pulled as far as needed
+	evens := naturals.Filter(func(n int) bool { return n%2 == 0 }).Limit(3)
+	assert.Equal([]int{2, 4, 6}, evens.ToSlice())
+	assert.Equal(6, pulled)
+
+	first, ok := naturals.Skip(10).FindFirst()
+	assert.Equal(11, first)
+	assert.ShouldBeTrue(ok)
+	assert.ShouldBeTrue(naturals.AnyMatch(func(n int) bool { return n > 5 }))
+
+	// each terminal operation runs the pipeline again
+	assert.Equal(3, evens.Count())
+	assert.Equal([]int{2, 4, 6}, evens.Reverse().Reverse().ToSlice())
+
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+	s := FromChannel(ch)
+	assert.Equal([]int{1, 2}, s.Limit(2).ToSlice())
+	assert.Equal([]int{1, 2, 3}, s.ToSlice())
+	assert.Equal(3, s.Count())
+
+	assert.Equal([]int{1, 2, 3, 4}, Concat(FromRange(1, 2, 1), Of(3, 4)).ToSlice())
+}
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestMap")
+
+	s := Map(Of(1, 2, 3), func(n int) string { return fmt.Sprint("n", n) })
+	assert.Equal([]string{"n1", "n2", "n3"}, s.ToSlice())
+
+	words := FlatMap(Of("a b", "", "c"), func(s string) Stream[string] {
+		if s == "" {
+			return FromSlice([]string{})
+		}
+		return FromSlice(strings.Split(s, " "))
+	})
+	assert.Equal([]string{"a", "b", "c"}, words.ToSlice())
+}
+
+func TestGroupByAndPartition(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestGroupByAndPartition")
+
+	s := Of(1, 2, 3, 4, 5)
+
+	groups := GroupBy(s, func(n int) bool { return n%2 == 0 })
+	assert.Equal(map[bool][]int{true: {2, 4}, false: {1, 3, 5}}, groups)
+
+	even, odd := Partition(s, func(n int) bool { return n%2 == 0 })
+	assert.Equal([]int{2, 4}, even)
+	assert.Equal([]int{1, 3, 5}, odd)
+}
+
+func TestZip(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestZip")
+
+	naturals := Generate(func() func() (int, bool) {
+		n := 0
+		return func() (int, bool) {
+			n++
+			return n, true
+		}
+	})
+
+	zipped := Zip(Of("a", "b"), naturals)
+	assert.Equal([]tuple.Tuple2[string, int]{
+		tuple.NewTuple2("a", 1),
+		tuple.NewTuple2("b", 2),
+	}, zipped.ToSlice())
+}
+
+func TestChunkAndWindow(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestChunkAndWindow")
+
+	s := Of(1, 2, 3, 4, 5)
+
+	assert.Equal([][]int{{1, 2}, {3, 4}, {5}}, Chunk(s, 2).ToSlice())
+	assert.Equal([][]int{{1, 2, 3, 4, 5}}, Chunk(s, 5).ToSlice())
+	assert.Equal([][]int{}, Chunk(FromSlice([]int{}), 2).ToSlice())
+
+	assert.Equal([][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}, Window(s, 3, 1).ToSlice())
+	assert.Equal([][]int{{1, 2, 3}, {3, 4, 5}}, Window(s, 3, 2).ToSlice())
+	assert.Equal([][]int{{1}, {4}}, Window(s, 1, 3).ToSlice())
+	assert.Equal([][]int{{1, 2}, {4, 5}}, Window(s, 2, 3).ToSlice())
+}
+
+func TestToMapAndToSet(t *testing.T) {
+	t.Parallel()
+
+	assert := internal.NewAssert(t, "TestToMapAndToSet")
+
+	m := ToMap(Of("a", "bb", "cc"), func(s string) int { return len(s) }, func(s string) string { return s })
+	assert.Equal(map[int]string{1: "a", 2: "cc"}, m)
+
+	s := ToSet(Of(1, 2, 2, 3))
+	assert.Equal(3, s.Size())
+	assert.ShouldBeTrue(s.Contain(2))
+}